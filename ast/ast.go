@@ -11,37 +11,70 @@ type Node interface {
 	nodeType()
 }
 
-// Program 表示 pl 文件对应的语法树.
+// Program 表示 pl 文件对应的语法树, 也可以由多个文件合并而成.
 type Program struct {
-	FileName string // 文件名
-	Source   string // 源代码
+	FileName string         // 文件名, 由多个文件合并时为主程序所在的文件
+	Source   string         // 源代码
+	FileSet  *token.FileSet `json:"-"` // 位置信息
 
-	Const   []*ConstDecl // 全局常量
-	Globals []*VarDecl   // 全局变量
-	Funcs   []*ProcDecl  // 函数列表
-	Stmt    *BlockStmt   // 程序入口
+	Name    string        // 模块名, 仅被导入的程序有
+	Imports []*ImportDecl // 导入的模块
+	Const   []*ConstDecl  // 全局常量
+	Globals []*VarDecl    // 全局变量
+	Funcs   []*ProcDecl   // 函数列表
+	Stmt    *BlockStmt    // 程序入口
+
+	Comments []*CommentGroup // 文件中全部的注释, 按位置排序
+}
+
+// Comment 一条注释, Text 包含定界符
+type Comment struct {
+	TextPos token.Pos // 注释开始的位置
+	Text    string    // 注释的内容
+}
+
+// CommentGroup 相邻的一组注释, 中间没有空行和其它记号
+type CommentGroup struct {
+	List []*Comment
+}
+
+// ImportDecl 导入声明: import "lib/math.pl";
+type ImportDecl struct {
+	Doc       *CommentGroup // 前导注释
+	ImportPos token.Pos     // import 关键字位置
+	PathPos   token.Pos     // 路径字符串的位置
+	Path      string        // 路径, 不含引号, 相对于所在的文件
+	Module    *Program      // 导入的模块, 由 build 加载
+	Comment   *CommentGroup // 行尾注释
 }
 
 // ConstDecl 常量信息
 type ConstDecl struct {
+	Doc        *CommentGroup // 前导注释
 	ConstPos   token.Pos     // const 关键字位置
 	Definition []*DefineStmt // 赋值语句
+	Comment    *CommentGroup // 行尾注释
 }
 
 // VarDecl 变量信息
 type VarDecl struct {
-	VarPos token.Pos // var 关键字位置
-	Names  []*Ident  // 变量名字
+	Doc     *CommentGroup // 前导注释
+	VarPos  token.Pos     // var 关键字位置
+	Names   []*Ident      // 变量名字
+	Comment *CommentGroup // 行尾注释
 }
 
 // ProcDecl 函数信息
 type ProcDecl struct {
+	Doc     *CommentGroup // 前导注释
 	FuncPos token.Pos
 	NamePos token.Pos
 	Name    string
 	VarDecl *VarDecl
 	Params  *FieldList
+	Funcs   []*ProcDecl // 嵌套的过程
 	Body    *BlockStmt
+	Comment *CommentGroup // 行尾注释
 }
 
 // FieldList 参数/属性 列表
@@ -58,14 +91,17 @@ type Field struct {
 
 // BlockStmt 块语句
 type BlockStmt struct {
-	BeginPos token.Pos // 'begin'
-	List     []Stmt
-	EndPos   token.Pos // 'end'
+	Doc       *CommentGroup // 前导注释
+	BeginPos  token.Pos     // 'begin'
+	List      []Stmt
+	EndPos    token.Pos     // 'end'
+	Semicolon token.Pos     // 没有 begin/end 且只含空语句时, 空语句的 ';'
+	Comment   *CommentGroup // 行尾注释
 }
 
+// Stmt 语句结点
 type Stmt interface {
-	Pos() token.Pos
-	End() token.Pos
+	Node
 	stmtType()
 }
 
@@ -75,9 +111,11 @@ type ExprStmt struct {
 
 // AssignStmt 表示一个赋值语句节点.
 type AssignStmt struct {
-	Target *Ident    // 要赋值的目标
-	OpPos  token.Pos // Op 的位置
-	Value  Expr      // 值
+	Doc     *CommentGroup // 前导注释
+	Target  *Ident        // 要赋值的目标
+	OpPos   token.Pos     // Op 的位置
+	Value   Expr          // 值
+	Comment *CommentGroup // 行尾注释
 }
 
 // DefineStmt 表示一个赋值语句节点.
@@ -89,36 +127,46 @@ type DefineStmt struct {
 
 // IfStmt 表示一个 if 语句节点.
 type IfStmt struct {
-	If   token.Pos  // if 关键字的位置
-	Cond Expr       // if 条件, *BinaryExpr
-	Body *BlockStmt // if 为真时对应的语句列表
-	Else Stmt       // else 对应的语句
+	Doc     *CommentGroup // 前导注释
+	If      token.Pos     // if 关键字的位置
+	Cond    Expr          // if 条件, *BinaryExpr
+	Body    *BlockStmt    // if 为真时对应的语句列表
+	ElsePos token.Pos     // else 关键字的位置, 没有 else 时无效
+	Else    Stmt          // else 对应的语句
+	Comment *CommentGroup // 行尾注释
 }
 
 // WhileStmt 表示一个 while 语句节点.
 type WhileStmt struct {
-	While token.Pos  // while 关键字的位置
-	Cond  Expr       // 条件表达式
-	Body  *BlockStmt // 循环对应的语句列表
+	Doc     *CommentGroup // 前导注释
+	While   token.Pos     // while 关键字的位置
+	Cond    Expr          // 条件表达式
+	Body    *BlockStmt    // 循环对应的语句列表
+	Comment *CommentGroup // 行尾注释
 }
 
 // RepeatStmt 表示一个 repeat 语句节点.
 type RepeatStmt struct {
-	Repeat token.Pos  // repeat 关键字的位置
-	Cond   Expr       // 条件表达式
-	Body   *BlockStmt // 循环对应的语句列表
+	Doc      *CommentGroup // 前导注释
+	Repeat   token.Pos     // repeat 关键字的位置
+	Cond     Expr          // 条件表达式
+	Body     *BlockStmt    // 循环对应的语句列表
+	UntilPos token.Pos     // until 关键字的位置
+	Comment  *CommentGroup // 行尾注释
 }
 
 // IOStmt 表示一个 read/write 语句节点.
 type IOStmt struct {
-	IOPos  token.Pos       // IO 关键字发位置
-	Type   token.TokenType // IO类型
-	Params *FieldList      // IO对象
+	Doc     *CommentGroup   // 前导注释
+	IOPos   token.Pos       // IO 关键字发位置
+	Type    token.TokenType // IO类型
+	Params  *FieldList      // IO对象
+	Comment *CommentGroup   // 行尾注释
 }
 
+// Expr 表达式结点
 type Expr interface {
-	Pos() token.Pos
-	End() token.Pos
+	Node
 	exprType()
 }
 
@@ -159,9 +207,11 @@ type ParenExpr struct {
 
 // CallStmt 表示一个函数调用
 type CallStmt struct {
-	ProcedureName *Ident    // 函数名字
-	CallPos       token.Pos // 位置
-	Lparen        token.Pos // '(' 位置
-	Args          []Expr    // 调用参数列表
-	Rparen        token.Pos // ')' 位置
+	Doc           *CommentGroup // 前导注释
+	ProcedureName *Ident        // 函数名字
+	CallPos       token.Pos     // 位置
+	Lparen        token.Pos     // '(' 位置
+	Args          []Expr        // 调用参数列表
+	Rparen        token.Pos     // ')' 位置
+	Comment       *CommentGroup // 行尾注释
 }
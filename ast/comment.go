@@ -0,0 +1,60 @@
+package ast
+
+import (
+	"pl0Compiler/token"
+	"strings"
+)
+
+func (c *Comment) Pos() token.Pos {
+	return c.TextPos
+}
+
+func (c *Comment) End() token.Pos {
+	return c.TextPos + token.Pos(len(c.Text))
+}
+
+func (c *Comment) nodeType() {
+
+}
+
+func (g *CommentGroup) Pos() token.Pos {
+	return g.List[0].Pos()
+}
+
+func (g *CommentGroup) End() token.Pos {
+	return g.List[len(g.List)-1].End()
+}
+
+func (g *CommentGroup) nodeType() {
+
+}
+
+// Text 返回去掉定界符后的注释内容, 每行去掉首尾空白, 行之间以 '\n' 分隔.
+// 首尾的空行被去掉, g 为 nil 时返回空字符串.
+func (g *CommentGroup) Text() string {
+	if g == nil {
+		return ""
+	}
+	var lines []string
+	for _, c := range g.List {
+		text := c.Text
+		switch {
+		case strings.HasPrefix(text, "//"):
+			text = text[2:]
+		case strings.HasPrefix(text, "(*"):
+			text = strings.TrimSuffix(text[2:], "*)")
+		case strings.HasPrefix(text, "{"):
+			text = strings.TrimSuffix(text[1:], "}")
+		}
+		for _, line := range strings.Split(text, "\n") {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	for len(lines) != 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) != 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -20,18 +20,17 @@ func (p *Program) JSONString() string {
 }
 
 type printer struct {
-	output   io.Writer
-	fileName string
-	source   string
-	ptrMap   map[interface{}]int
-	indent   int
-	last     byte
-	line     int
+	output io.Writer
+	fset   *token.FileSet
+	ptrMap map[interface{}]int
+	indent int
+	last   byte
+	line   int
 }
 
 // Print 打印语法树到 stdout
 func Print(node Node) {
-	fprint(os.Stdout, "", "", node)
+	fprint(os.Stdout, nil, node)
 }
 
 func (p *printer) Write(data []byte) (n int, err error) {
@@ -110,7 +109,7 @@ func (p *printer) print(x reflect.Value) {
 		if line, exists := p.ptrMap[ptr]; exists {
 			p.printf("(obj @ %d)", line)
 		} else {
-			p.ptrMap[ptr] = line
+			p.ptrMap[ptr] = p.line
 			p.print(x.Elem())
 		}
 	case reflect.Array:
@@ -171,8 +170,8 @@ func (p *printer) print(x reflect.Value) {
 			p.printf("%q", v)
 			return
 		case token.Pos:
-			if p.fileName != "" && p.source != "" {
-				p.printf("%s", v.Position(p.fileName, p.source))
+			if p.fset != nil {
+				p.printf("%s", p.fset.Position(v))
 				return
 			}
 		}
@@ -189,26 +188,26 @@ func (p *printer) notNilFilter(_name string, v reflect.Value) bool {
 	return true
 }
 
-func Fprint(w io.Writer, fileName, source string, node Node) {
-	fprint(w, fileName, source, node)
+// Fprint 打印语法树, fset 为 nil 时位置按整数打印 (Program 使用自身的 FileSet)
+func Fprint(w io.Writer, fset *token.FileSet, node Node) {
+	fprint(w, fset, node)
 }
 
-func fprint(w io.Writer, fileName, source string, x interface{}) (err error) {
+func fprint(w io.Writer, fset *token.FileSet, x interface{}) (err error) {
 	p := printer{
-		output:   w,
-		fileName: fileName,
-		source:   source,
-		ptrMap:   make(map[interface{}]int),
-		last:     '\n', // force printing of line number on first line
+		output: w,
+		fset:   fset,
+		ptrMap: make(map[interface{}]int),
+		last:   '\n', // force printing of line number on first line
 	}
 
 	if f, ok := x.(*Program); ok {
-		if p.fileName == "" && p.source == "" {
-			p.fileName = f.FileName
-			p.source = f.Source
+		if p.fset == nil {
+			p.fset = f.FileSet
 		}
 
 		file := *f
+		file.FileSet = nil
 		if len(file.Source) > 8 {
 			file.Source = file.Source[:8] + "..."
 		}
@@ -234,6 +233,6 @@ func fprint(w io.Writer, fileName, source string, x interface{}) (err error) {
 
 func (p *Program) String() string {
 	var buf bytes.Buffer
-	Fprint(&buf, p.FileName, p.Source, p)
+	Fprint(&buf, p.FileSet, p)
 	return buf.String()
 }
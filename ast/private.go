@@ -2,120 +2,252 @@ package ast
 
 import "pl0Compiler/token"
 
+// Pos 为第一个声明的位置. 由多个文件合并的程序, 范围覆盖所有文件中的声明.
 func (p *Program) Pos() token.Pos {
-	return token.NoPos
+	pos := token.NoPos
+	p.decls(func(n Node) {
+		if x := n.Pos(); x.IsValid() && (!pos.IsValid() || x < pos) {
+			pos = x
+		}
+	})
+	return pos
 }
 
 func (p *Program) End() token.Pos {
-	return token.NoPos
+	end := token.NoPos
+	p.decls(func(n Node) {
+		if x := n.End(); x > end {
+			end = x
+		}
+	})
+	return end
+}
+
+// decls 依次访问程序的顶层声明和主程序
+func (p *Program) decls(f func(Node)) {
+	for _, i := range p.Imports {
+		f(i)
+	}
+	for _, c := range p.Const {
+		f(c)
+	}
+	for _, g := range p.Globals {
+		f(g)
+	}
+	for _, fn := range p.Funcs {
+		f(fn)
+	}
+	if p.Stmt != nil {
+		f(p.Stmt)
+	}
 }
 
 func (p *Program) nodeType() {
 
 }
 
-func (i Ident) Pos() token.Pos {
-	return token.NoPos
+func (i ImportDecl) Pos() token.Pos {
+	return i.ImportPos
 }
 
-func (i Ident) End() token.Pos {
-	return token.NoPos
+func (i ImportDecl) End() token.Pos {
+	return i.PathPos + token.Pos(len(i.Path)+2)
 }
 
-func (i Ident) exprType() {
+func (i ImportDecl) nodeType() {
 
 }
 
-func (b BinaryExpr) Pos() token.Pos {
-	return token.NoPos
+func (c ConstDecl) Pos() token.Pos {
+	return c.ConstPos
 }
 
-func (b BinaryExpr) End() token.Pos {
-	return token.NoPos
+func (c ConstDecl) End() token.Pos {
+	if n := len(c.Definition); n != 0 {
+		return c.Definition[n-1].End()
+	}
+	return c.ConstPos + token.Pos(len("const"))
 }
 
-func (b BinaryExpr) exprType() {
+func (c ConstDecl) nodeType() {
 
 }
 
-func (c CallStmt) Pos() token.Pos {
-	return token.NoPos
+func (v VarDecl) Pos() token.Pos {
+	return v.VarPos
 }
 
-func (c CallStmt) End() token.Pos {
-	return token.NoPos
+func (v VarDecl) End() token.Pos {
+	if n := len(v.Names); n != 0 {
+		return v.Names[n-1].End()
+	}
+	return v.VarPos + token.Pos(len("var"))
 }
 
-func (c CallStmt) exprType() {
+func (v VarDecl) nodeType() {
 
 }
 
-func (u UnaryExpr) Pos() token.Pos {
-	return token.NoPos
+func (v VarDecl) stmtType() {
+
 }
 
-func (u UnaryExpr) End() token.Pos {
-	return token.NoPos
+func (p ProcDecl) Pos() token.Pos {
+	return p.FuncPos
 }
 
-func (u UnaryExpr) exprType() {
+// End 为过程体 end 之后的位置, 没有过程体时为过程头的结束位置
+func (p ProcDecl) End() token.Pos {
+	end := p.NamePos + token.Pos(len(p.Name))
+	if p.Params != nil && p.Params.End().IsValid() {
+		end = p.Params.End()
+	}
+	if p.VarDecl != nil {
+		end = p.VarDecl.End()
+	}
+	if n := len(p.Funcs); n != 0 {
+		end = p.Funcs[n-1].End()
+	}
+	if p.Body != nil {
+		end = p.Body.End()
+	}
+	return end
+}
+
+func (p ProcDecl) nodeType() {
 
 }
 
-func (e ExprStmt) Pos() token.Pos {
+func (f FieldList) Pos() token.Pos {
+	if f.Opening.IsValid() {
+		return f.Opening
+	}
+	if len(f.List) != 0 {
+		return f.List[0].Pos()
+	}
 	return token.NoPos
 }
 
-func (e ExprStmt) End() token.Pos {
+func (f FieldList) End() token.Pos {
+	if f.Closing.IsValid() {
+		return f.Closing + 1
+	}
+	if n := len(f.List); n != 0 {
+		return f.List[n-1].End()
+	}
 	return token.NoPos
 }
 
-func (e ExprStmt) stmtType() {
+func (f FieldList) nodeType() {
+
+}
+
+func (f Field) Pos() token.Pos {
+	return f.Name.Pos()
+}
 
+func (f Field) End() token.Pos {
+	return f.Name.End()
 }
 
+func (f Field) nodeType() {
+
+}
+
+// Pos 为 begin 的位置. 由单条语句构成的块没有 begin/end, 范围由其中的语句决定,
+// 空语句的范围是它的 ';'.
 func (b BlockStmt) Pos() token.Pos {
-	return token.NoPos
+	if b.BeginPos.IsValid() {
+		return b.BeginPos
+	}
+	for _, x := range b.List {
+		if x != nil {
+			return x.Pos()
+		}
+	}
+	return b.Semicolon
 }
 
 func (b BlockStmt) End() token.Pos {
+	if b.EndPos.IsValid() {
+		return b.EndPos + token.Pos(len("end"))
+	}
+	for i := len(b.List) - 1; i >= 0; i-- {
+		if b.List[i] != nil {
+			return b.List[i].End()
+		}
+	}
+	if b.Semicolon.IsValid() {
+		return b.Semicolon + 1
+	}
 	return token.NoPos
 }
 
+func (b BlockStmt) nodeType() {
+
+}
+
 func (b BlockStmt) stmtType() {
 
 }
 
-func (v VarDecl) Pos() token.Pos {
-	return token.NoPos
+func (e ExprStmt) Pos() token.Pos {
+	return e.X.Pos()
 }
 
-func (v VarDecl) End() token.Pos {
-	return token.NoPos
+func (e ExprStmt) End() token.Pos {
+	return e.X.End()
 }
 
-func (v VarDecl) stmtType() {
+func (e ExprStmt) nodeType() {
+
+}
+
+func (e ExprStmt) stmtType() {
 
 }
 
 func (a AssignStmt) Pos() token.Pos {
-	return token.NoPos
+	return a.Target.Pos()
 }
 
 func (a AssignStmt) End() token.Pos {
-	return token.NoPos
+	return a.Value.End()
+}
+
+func (a AssignStmt) nodeType() {
+
 }
 
 func (a AssignStmt) stmtType() {
 
 }
 
+func (d DefineStmt) Pos() token.Pos {
+	return d.Target.Pos()
+}
+
+func (d DefineStmt) End() token.Pos {
+	return d.Value.End()
+}
+
+func (d DefineStmt) nodeType() {
+
+}
+
 func (i IfStmt) Pos() token.Pos {
-	return token.NoPos
+	return i.If
 }
 
 func (i IfStmt) End() token.Pos {
-	return token.NoPos
+	if i.Else != nil {
+		return i.Else.End()
+	}
+	return i.Body.End()
+}
+
+func (i IfStmt) nodeType() {
+
 }
 
 func (i IfStmt) stmtType() {
@@ -123,97 +255,155 @@ func (i IfStmt) stmtType() {
 }
 
 func (w WhileStmt) Pos() token.Pos {
-	return token.NoPos
+	return w.While
 }
 
 func (w WhileStmt) End() token.Pos {
-	return token.NoPos
+	return w.Body.End()
+}
+
+func (w WhileStmt) nodeType() {
+
 }
 
 func (w WhileStmt) stmtType() {
 
 }
 
-func (n Number) Pos() token.Pos {
-	return token.NoPos
+func (r RepeatStmt) Pos() token.Pos {
+	return r.Repeat
 }
 
-func (n Number) End() token.Pos {
-	return token.NoPos
+func (r RepeatStmt) End() token.Pos {
+	return r.Cond.End()
 }
 
-func (n Number) exprType() {
+func (r RepeatStmt) nodeType() {
 
 }
 
-func (c CallStmt) stmtType() {
+func (r RepeatStmt) stmtType() {
 
 }
 
-func (i Ident) nodeType() {
+func (I IOStmt) Pos() token.Pos {
+	return I.IOPos
+}
 
+func (I IOStmt) End() token.Pos {
+	return I.Params.End()
 }
 
-func (p ParenExpr) exprType() {
+func (I IOStmt) nodeType() {
 
 }
 
-func (p ProcDecl) Pos() token.Pos {
-	return token.NoPos
+func (I IOStmt) stmtType() {
+
 }
 
-func (p ProcDecl) End() token.Pos {
-	return token.NoPos
+func (c CallStmt) Pos() token.Pos {
+	if c.CallPos.IsValid() {
+		return c.CallPos
+	}
+	return c.ProcedureName.Pos()
 }
 
-func (p ProcDecl) nodeType() {
+func (c CallStmt) End() token.Pos {
+	if c.Rparen.IsValid() {
+		return c.Rparen + 1
+	}
+	return c.ProcedureName.End()
+}
+
+func (c CallStmt) nodeType() {
 
 }
 
-func (p ParenExpr) Pos() token.Pos {
-	return token.NoPos
+func (c CallStmt) stmtType() {
+
 }
 
-func (p ParenExpr) End() token.Pos {
-	return token.NoPos
+func (c CallStmt) exprType() {
+
 }
 
-func (v VarDecl) nodeType() {
+func (i Ident) Pos() token.Pos {
+	return i.NamePos
+}
 
+func (i Ident) End() token.Pos {
+	return i.NamePos + token.Pos(len(i.Name))
 }
 
-func (d DefineStmt) Pos() token.Pos {
-	return token.NoPos
+func (i Ident) nodeType() {
+
 }
 
-func (d DefineStmt) End() token.Pos {
-	return token.NoPos
+func (i Ident) exprType() {
+
 }
 
-func (d DefineStmt) nodeType() {
+func (n Number) Pos() token.Pos {
+	return n.ValuePos
+}
 
+func (n Number) End() token.Pos {
+	return n.ValueEnd
 }
 
-func (r RepeatStmt) Pos() token.Pos {
-	return token.NoPos
+func (n Number) nodeType() {
+
 }
 
-func (r RepeatStmt) End() token.Pos {
-	return token.NoPos
+func (n Number) exprType() {
+
 }
 
-func (r RepeatStmt) stmtType() {
+func (b BinaryExpr) Pos() token.Pos {
+	return b.X.Pos()
+}
 
+func (b BinaryExpr) End() token.Pos {
+	return b.Y.End()
 }
 
-func (I IOStmt) Pos() token.Pos {
-	return token.NoPos
+func (b BinaryExpr) nodeType() {
+
 }
 
-func (I IOStmt) End() token.Pos {
-	return token.NoPos
+func (b BinaryExpr) exprType() {
+
 }
 
-func (I IOStmt) stmtType() {
+func (u UnaryExpr) Pos() token.Pos {
+	return u.OpPos
+}
+
+func (u UnaryExpr) End() token.Pos {
+	return u.X.End()
+}
+
+func (u UnaryExpr) nodeType() {
+
+}
+
+func (u UnaryExpr) exprType() {
+
+}
+
+func (p ParenExpr) Pos() token.Pos {
+	return p.Lparen
+}
+
+func (p ParenExpr) End() token.Pos {
+	return p.Rparen + 1
+}
+
+func (p ParenExpr) nodeType() {
+
+}
+
+func (p ParenExpr) exprType() {
 
 }
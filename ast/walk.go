@@ -0,0 +1,133 @@
+package ast
+
+import "fmt"
+
+// Visitor 的 Visit 方法在 Walk 遇到每个结点时调用.
+// 返回的 w 不为 nil 时, Walk 用 w 访问结点的子结点, 最后调用 w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk 以深度优先的顺序遍历语法树, 跳过为 nil 的结点.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, i := range n.Imports {
+			Walk(v, i)
+		}
+		for _, c := range n.Const {
+			Walk(v, c)
+		}
+		for _, g := range n.Globals {
+			Walk(v, g)
+		}
+		for _, fn := range n.Funcs {
+			Walk(v, fn)
+		}
+		if n.Stmt != nil {
+			Walk(v, n.Stmt)
+		}
+	case *ImportDecl:
+		// 不进入导入的模块
+	case *ConstDecl:
+		for _, def := range n.Definition {
+			Walk(v, def)
+		}
+	case *VarDecl:
+		for _, name := range n.Names {
+			Walk(v, name)
+		}
+	case *ProcDecl:
+		// 没有参数的过程不含参数列表的源码, 不访问
+		if n.Params != nil && n.Params.Pos().IsValid() {
+			Walk(v, n.Params)
+		}
+		if n.VarDecl != nil {
+			Walk(v, n.VarDecl)
+		}
+		for _, fn := range n.Funcs {
+			Walk(v, fn)
+		}
+		if n.Body != nil {
+			Walk(v, n.Body)
+		}
+	case *FieldList:
+		for _, f := range n.List {
+			Walk(v, f)
+		}
+	case *Field:
+		Walk(v, n.Name)
+
+	case *BlockStmt:
+		walkStmtList(v, n.List)
+	case *ExprStmt:
+		Walk(v, n.X)
+	case *AssignStmt:
+		Walk(v, n.Target)
+		Walk(v, n.Value)
+	case *DefineStmt:
+		Walk(v, n.Target)
+		Walk(v, n.Value)
+	case *IfStmt:
+		Walk(v, n.Cond)
+		Walk(v, n.Body)
+		if n.Else != nil {
+			Walk(v, n.Else)
+		}
+	case *WhileStmt:
+		Walk(v, n.Cond)
+		Walk(v, n.Body)
+	case *RepeatStmt:
+		Walk(v, n.Body)
+		Walk(v, n.Cond)
+	case *IOStmt:
+		Walk(v, n.Params)
+	case *CallStmt:
+		Walk(v, n.ProcedureName)
+		for _, arg := range n.Args {
+			Walk(v, arg)
+		}
+
+	case *Ident, *Number:
+		// 没有子结点
+	case *BinaryExpr:
+		Walk(v, n.X)
+		Walk(v, n.Y)
+	case *UnaryExpr:
+		Walk(v, n.X)
+	case *ParenExpr:
+		Walk(v, n.X)
+
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+
+	v.Visit(nil)
+}
+
+func walkStmtList(v Visitor, list []Stmt) {
+	for _, x := range list {
+		if x != nil {
+			Walk(v, x)
+		}
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect 以深度优先的顺序遍历语法树, 对每个结点调用 f(node).
+// f 返回 true 时继续访问子结点, 之后调用 f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -9,27 +9,35 @@ import (
 	"os/exec"
 	"pl0Compiler/ast"
 	"pl0Compiler/builtin"
+	"pl0Compiler/check"
 	"pl0Compiler/compiler"
+	"pl0Compiler/format"
+	"pl0Compiler/interp"
+	"pl0Compiler/ir"
 	"pl0Compiler/lexer"
 	"pl0Compiler/parser"
+	"pl0Compiler/pcode"
 	"pl0Compiler/token"
+	"pl0Compiler/vet"
 	"runtime"
 	"strings"
 )
 
 type Option struct {
-	Debug   bool
-	GOOS    string
-	GOARCH  string
-	Clang   string
-	WasmLLC string
-	WasmLD  string
+	Debug         bool
+	Interp        bool // run 时使用解释器执行, 不依赖 clang
+	CheckDiv      bool // 生成的代码在除数为零或最小的整数除以 -1 时报告所在的行
+	CheckOverflow bool // 生成的代码在整数溢出时报告所在的文件和行
+	IntWidth      int  // 整数的位数, 32 或 64, 为 0 时是 32
+	GOOS          string
+	GOARCH        string
+	Clang         string
+	WasmLLC       string
+	WasmLD        string
 }
 
 type Context struct {
-	opt  Option
-	path string
-	src  string
+	opt Option
 }
 
 func NewContext(opt *Option) *Context {
@@ -47,6 +55,9 @@ func NewContext(opt *Option) *Context {
 			p.opt.Clang = "clang"
 		}
 	}
+	if p.opt.IntWidth == 0 {
+		p.opt.IntWidth = 32
+	}
 	if p.opt.GOOS == "" {
 		p.opt.GOOS = runtime.GOOS
 	}
@@ -61,7 +72,7 @@ func (p *Context) Lex(fileName string, src interface{}) (tokens, comments []toke
 	if err != nil {
 		return nil, nil, err
 	}
-	l := lexer.NewLexer(fileName, code)
+	l := lexer.NewLexer(token.NewFileSet().AddFile(fileName, code), code)
 	tokens = l.Tokens()
 	comments = l.Comments()
 	return
@@ -72,36 +83,121 @@ func (p *Context) AST(fileName string, src interface{}) (f *ast.Program, err err
 	if err != nil {
 		return nil, err
 	}
-	f, err = parser.ParseFile(fileName, code)
+	f, err = parser.ParseFile(token.NewFileSet(), fileName, code)
 	if err != nil {
 		return nil, err
 	}
 	return
 }
 
-func (p *Context) ASM(fileName string, src interface{}) (ll string, err error) {
+// Format 格式化一个 pl/0 源文件, 返回格式化后的源代码
+func (p *Context) Format(fileName string, src interface{}) ([]byte, error) {
 	code, err := p.readSource(fileName, src)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	return format.Source(fileName, []byte(code))
+}
+
+// ParseFiles 解析组成同一个程序的多个源文件并合并为一个语法树, 同时加载导入的模块.
+// srcs 为 nil 时从文件中读取源代码, 否则与 fileNames 一一对应.
+func (p *Context) ParseFiles(fileNames []string, srcs []interface{}) (f *ast.Program, err error) {
+	codes := make([]string, len(fileNames))
+	for i, fileName := range fileNames {
+		var src interface{}
+		if srcs != nil {
+			src = srcs[i]
+		}
+		if codes[i], err = p.readSource(fileName, src); err != nil {
+			return nil, err
+		}
 	}
-	f, err := parser.ParseFile(fileName, code)
+	f, err = parser.ParseFiles(token.NewFileSet(), fileNames, codes)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if err = p.loadImports(f); err != nil {
+		return nil, err
 	}
-	ll = new(compiler.Compiler).Compile(f)
 	return
 }
 
-func (p *Context) Build(fileName string, src interface{}, outFIle string) (output []byte, err error) {
-	return p.build(fileName, src, outFIle, p.opt.GOOS, p.opt.GOARCH)
+// Check 解析并做语义检查, 返回可以交给后端的语法树. info 可以为 nil.
+func (p *Context) Check(fileNames []string, srcs []interface{}, info *check.Info) (f *ast.Program, err error) {
+	f, err = p.ParseFiles(fileNames, srcs)
+	if err != nil {
+		return nil, err
+	}
+	checker := check.NewChecker(info)
+	checker.SetIntWidth(p.opt.IntWidth)
+	if err = checker.Check(f); err != nil {
+		return nil, err
+	}
+	return
 }
 
-func (p *Context) build(fileName string, src interface{}, outFile, goos, goarch string) (output []byte, err error) {
-	code, err := p.readSource(fileName, src)
+func (p *Context) ASM(fileNames []string, srcs []interface{}) (ll string, err error) {
+	info := new(check.Info)
+	f, err := p.Check(fileNames, srcs, info)
+	if err != nil {
+		return "", err
+	}
+	m := compiler.NewCompiler(p.compilerOption(info)).CompileModule(f)
+	if err = ir.Verify(m); err != nil {
+		return "", err
+	}
+	return m.String(), nil
+}
+
+// Verify 编译程序并校验生成的 IR, 返回校验发现的问题
+func (p *Context) Verify(fileNames []string, srcs []interface{}) (err error) {
+	info := new(check.Info)
+	f, err := p.Check(fileNames, srcs, info)
+	if err != nil {
+		return err
+	}
+	m := compiler.NewCompiler(p.compilerOption(info)).CompileModule(f)
+	return ir.Verify(m)
+}
+
+func (p *Context) compilerOption(info *check.Info) *compiler.Option {
+	return &compiler.Option{
+		Types:         info.Types,
+		CheckDiv:      p.opt.CheckDiv,
+		CheckOverflow: p.opt.CheckOverflow,
+		IntWidth:      p.opt.IntWidth,
+	}
+}
+
+// PCode 把程序编译为 p-code. 虚拟机只支持 32 位整数, IntWidth 为其它值时报错.
+func (p *Context) PCode(fileNames []string, srcs []interface{}) (prog *pcode.Program, err error) {
+	if p.opt.IntWidth != 32 {
+		return nil, fmt.Errorf("p-code vm only supports 32-bit integers, not %d-bit", p.opt.IntWidth)
+	}
+	f, err := p.Check(fileNames, srcs, nil)
 	if err != nil {
 		return nil, err
 	}
-	f, err := parser.ParseFile(fileName, code)
+	return pcode.NewCompiler().Compile(f)
+}
+
+// Vet 在语义检查之后运行 opt 选择的静态检查, opt 为 nil 时运行全部检查
+func (p *Context) Vet(fileNames []string, srcs []interface{}, opt *vet.Option) ([]vet.Diagnostic, error) {
+	info := new(check.Info)
+	f, err := p.Check(fileNames, srcs, info)
+	if err != nil {
+		return nil, err
+	}
+	return vet.Vet(f, info, opt)
+}
+
+// Build 把一个或多个源文件编译为可执行文件
+func (p *Context) Build(fileNames []string, srcs []interface{}, outFIle string) (output []byte, err error) {
+	return p.build(fileNames, srcs, outFIle, p.opt.GOOS, p.opt.GOARCH)
+}
+
+func (p *Context) build(fileNames []string, srcs []interface{}, outFile, goos, goarch string) (output []byte, err error) {
+	ll, err := p.ASM(fileNames, srcs)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +219,6 @@ func (p *Context) build(fileName string, src interface{}, outFile, goos, goarch
 		return nil, err
 	}
 
-	ll := compiler.NewCompiler().Compile(f)
 	err = os.WriteFile(_a_out_ll, []byte(ll), 0666)
 	if err != nil {
 		return nil, err
@@ -139,13 +234,13 @@ func (p *Context) build(fileName string, src interface{}, outFile, goos, goarch
 
 		cmdLLC := exec.Command(p.opt.WasmLLC, "-march=wasm32", "-filetype=obj", "-o", _a_out_ll_o, _a_out_ll)
 		if data, err := cmdLLC.CombinedOutput(); err != nil {
-			return data, err
+			return data, toolError(p.opt.WasmLLC, err)
 		}
 
 		cmdWasmLD := exec.Command(p.opt.WasmLD, "--entry=main", "--allow-undefined",
 			"--export-all", _a_out_ll_o, "-o", outFile)
 		data, err := cmdWasmLD.CombinedOutput()
-		return data, err
+		return data, toolError(p.opt.WasmLD, err)
 	}
 	cmd := exec.Command(
 		p.opt.Clang, "-Wno-override-module", "-o", outFile,
@@ -153,10 +248,22 @@ func (p *Context) build(fileName string, src interface{}, outFile, goos, goarch
 	)
 
 	data, err := cmd.CombinedOutput()
-	return data, err
+	return data, toolError(p.opt.Clang, err)
 }
 
-func (p *Context) Run(fileName string, src interface{}) ([]byte, error) {
+// toolError 包装外部工具的错误. 不保留 *exec.ExitError,
+// 以免调用者把工具的退出码当作程序的退出码.
+func toolError(tool string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %v", tool, err)
+}
+
+func (p *Context) Run(fileNames []string, srcs []interface{}) ([]byte, error) {
+	if p.opt.Interp {
+		return p.runInterp(fileNames, srcs)
+	}
 	if p.opt.GOOS == "wasm" {
 		return nil, fmt.Errorf("donot support run wasm")
 	}
@@ -169,7 +276,7 @@ func (p *Context) Run(fileName string, src interface{}) ([]byte, error) {
 		defer os.Remove(a_out)
 	}
 
-	output, err := p.build(fileName, src, a_out, runtime.GOOS, runtime.GOARCH)
+	output, err := p.build(fileNames, srcs, a_out, runtime.GOOS, runtime.GOARCH)
 	if err != nil {
 		return output, err
 	}
@@ -181,6 +288,19 @@ func (p *Context) Run(fileName string, src interface{}) ([]byte, error) {
 	return output, nil
 }
 
+func (p *Context) runInterp(fileNames []string, srcs []interface{}) ([]byte, error) {
+	f, err := p.Check(fileNames, srcs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	ip := interp.NewInterp(f, os.Stdin, &buf)
+	ip.SetIntWidth(p.opt.IntWidth)
+	err = ip.Run()
+	return buf.Bytes(), err
+}
+
 func (p *Context) readSource(fileName string, src interface{}) (string, error) {
 	if src != nil {
 		switch s := src.(type) {
@@ -0,0 +1,112 @@
+package build_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"pl0Compiler/build"
+	"strings"
+	"testing"
+)
+
+// TestVerifyDemos 编译 demo 中的每个程序, 用 ir.Verify 校验生成的 IR
+func TestVerifyDemos(t *testing.T) {
+	var files []string
+	for _, pattern := range []string{"*.pl", "corpus/*.pl"} {
+		m, err := filepath.Glob(filepath.Join("../demo", pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, m...)
+	}
+	if len(files) == 0 {
+		t.Fatal("no demo files")
+	}
+
+	// test1.pl 调用 multiply 时使用了未声明的 test 和 foo, 不能通过检查
+	skip := map[string]bool{"test1.pl": true}
+
+	options := []build.Option{
+		{},
+		{CheckDiv: true, CheckOverflow: true},
+		{IntWidth: 64, CheckDiv: true, CheckOverflow: true},
+	}
+	for _, opt := range options {
+		ctx := build.NewContext(&opt)
+		for _, fileName := range files {
+			if skip[filepath.Base(fileName)] {
+				continue
+			}
+			if err := ctx.Verify([]string{fileName}, nil); err != nil {
+				t.Errorf("%s %+v: %v", fileName, opt, err)
+			}
+		}
+		multi := []string{"../demo/multi/main.pl", "../demo/multi/lib.pl"}
+		if err := ctx.Verify(multi, nil); err != nil {
+			t.Errorf("%v %+v: %v", multi, opt, err)
+		}
+	}
+}
+
+// TestOddIR 检查 odd 编译为最低位的测试, 而不是对负数得到 -1 的 srem
+func TestOddIR(t *testing.T) {
+	src := "var x; begin x := -3; if odd x then begin call println(1); end; end."
+	for _, width := range []int{32, 64} {
+		ll, err := build.NewContext(&build.Option{IntWidth: width}).ASM([]string{"test.pl"}, []interface{}{src})
+		if err != nil {
+			t.Fatalf("i%d: %v", width, err)
+		}
+		typ := fmt.Sprintf("i%d", width)
+		if strings.Contains(ll, "srem") {
+			t.Errorf("%s: odd uses srem:\n%s", typ, ll)
+		}
+		if !strings.Contains(ll, "and "+typ+" ") || !strings.Contains(ll, "icmp ne "+typ+" ") {
+			t.Errorf("%s: odd is not lowered to and/icmp ne:\n%s", typ, ll)
+		}
+	}
+}
+
+func TestPCodeIntWidth(t *testing.T) {
+	src := "var x; begin x := 1; read x; end."
+	if _, err := build.NewContext(nil).PCode([]string{"test.pl"}, []interface{}{src}); err != nil {
+		t.Fatalf("32-bit: %v", err)
+	}
+	_, err := build.NewContext(&build.Option{IntWidth: 64}).PCode([]string{"test.pl"}, []interface{}{src})
+	if err == nil || !strings.Contains(err.Error(), "32-bit") {
+		t.Fatalf("64-bit: err = %v, want an error about 32-bit integers", err)
+	}
+}
+
+// TestCheckDivIR 检查 CheckDiv 对最小的整数除以 -1 报告溢出, 而不是由 sdiv 和 srem 引发 SIGFPE
+func TestCheckDivIR(t *testing.T) {
+	src := "var x, y; begin write x; write y; x := x / y; y := x % y; read x, y; end."
+	for _, tt := range []struct {
+		width  int
+		minInt string
+	}{
+		{32, "-2147483648"},
+		{64, "-9223372036854775808"},
+	} {
+		ctx := build.NewContext(&build.Option{IntWidth: tt.width, CheckDiv: true})
+		if err := ctx.Verify([]string{"test.pl"}, []interface{}{src}); err != nil {
+			t.Fatalf("i%d: %v", tt.width, err)
+		}
+		ll, err := ctx.ASM([]string{"test.pl"}, []interface{}{src})
+		if err != nil {
+			t.Fatalf("i%d: %v", tt.width, err)
+		}
+		typ := fmt.Sprintf("i%d", tt.width)
+		for _, op := range []string{"sdiv", "srem"} {
+			i := strings.Index(ll, op+" "+typ)
+			if i < 0 {
+				t.Fatalf("%s: no %s:\n%s", typ, op, ll)
+			}
+			// 每个除法之前依次检查除数为零, 被除数为最小的整数且除数为 -1
+			before := ll[:i]
+			if j := strings.LastIndex(before, "@pl_0_builtin_divzero("); j < 0 ||
+				!strings.Contains(before[j:], ", "+tt.minInt) || !strings.Contains(before[j:], ", -1") ||
+				!strings.Contains(before[j:], "@pl_0_builtin_overflow(") {
+				t.Errorf("%s: %s is not guarded against %s / -1:\n%s", typ, op, tt.minInt, ll)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package build
+
+import (
+	"fmt"
+	"path/filepath"
+	"pl0Compiler/ast"
+	"pl0Compiler/lexer"
+	"pl0Compiler/parser"
+	"pl0Compiler/token"
+	"strings"
+)
+
+// loader 加载 import 声明导入的模块, 每个文件只解析一次.
+type loader struct {
+	ctx     *Context
+	fset    *token.FileSet
+	modules map[string]*ast.Program // 已加载的模块, 按清理后的路径
+	names   map[string]string       // 模块名对应的路径
+	loading map[string]bool         // 正在加载的文件, 用于发现循环导入
+	errors  lexer.ErrorList
+}
+
+// loadImports 加载程序导入的全部模块, 导入路径相对于 import 所在的文件
+func (p *Context) loadImports(program *ast.Program) error {
+	l := &loader{
+		ctx:     p,
+		fset:    program.FileSet,
+		modules: make(map[string]*ast.Program),
+		names:   make(map[string]string),
+		loading: make(map[string]bool),
+	}
+	for _, f := range l.fset.Files() {
+		l.loading[filepath.Clean(f.Name())] = true
+	}
+	l.importAll(program)
+	l.errors.Sort()
+	return l.errors.Err()
+}
+
+func (l *loader) errorf(pos token.Pos, format string, args ...interface{}) {
+	l.errors.Add(l.fset.Position(pos), fmt.Sprintf(format, args...))
+}
+
+func (l *loader) importAll(program *ast.Program) {
+	for _, imp := range program.Imports {
+		imp.Module = l.load(imp)
+	}
+}
+
+func (l *loader) load(imp *ast.ImportDecl) *ast.Program {
+	path := imp.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(l.fset.File(imp.Pos()).Name()), path)
+	}
+	path = filepath.Clean(path)
+
+	if module, ok := l.modules[path]; ok {
+		return module
+	}
+	if l.loading[path] {
+		l.errorf(imp.PathPos, "import cycle not allowed: %q", imp.Path)
+		return nil
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if !isModuleName(name) {
+		l.errorf(imp.PathPos, "invalid module name %q", name)
+		return nil
+	}
+	if other, ok := l.names[name]; ok {
+		l.errorf(imp.PathPos, "module name %s of %q conflicts with %s", name, imp.Path, other)
+		return nil
+	}
+
+	src, err := l.ctx.readSource(path, nil)
+	if err != nil {
+		l.errorf(imp.PathPos, "cannot import %q: %v", imp.Path, err)
+		return nil
+	}
+	module, err := parser.ParseFile(l.fset, path, src)
+	if err != nil {
+		if list, ok := err.(lexer.ErrorList); ok {
+			l.errors = append(l.errors, list...)
+		} else {
+			l.errorf(imp.PathPos, "cannot import %q: %v", imp.Path, err)
+		}
+		return nil
+	}
+	if module.Stmt != nil {
+		l.errorf(module.Stmt.Pos(), "imported module %s must not have a main block", name)
+	}
+	module.Name = name
+	l.names[name] = path
+
+	l.loading[path] = true
+	l.importAll(module)
+	delete(l.loading, path)
+
+	l.modules[path] = module
+	return module
+}
+
+// isModuleName 模块名用于生成代码中的名字, 只能包含字母, 数字和下划线
+func isModuleName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
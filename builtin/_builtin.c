@@ -11,7 +11,29 @@ int pl_0_builtin_write(){
     return x;
 }
 
+long long pl_0_builtin_write_i64(){
+    long long x;
+	scanf_s("%lld",&x);
+    return x;
+}
+
+int pl_0_builtin_println_i64(long long x){
+    return printf("%lld\n",x);
+}
+
 int pl_0_builtin_exit(int x){
     exit(x);
     return 0;
+}
+
+int pl_0_builtin_divzero(int line){
+    fprintf(stderr,"runtime error: division by zero at line %d\n",line);
+    exit(1);
+    return 0;
+}
+
+int pl_0_builtin_overflow(const char *file,int line){
+    fprintf(stderr,"runtime error: integer overflow at %s:%d\n",file,line);
+    exit(1);
+    return 0;
 }
\ No newline at end of file
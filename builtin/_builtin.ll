@@ -15,6 +15,8 @@ $printf = comdat any
 
 $scanf_s = comdat any
 
+$fprintf = comdat any
+
 $_vsprintf_l = comdat any
 
 $_vsnprintf_l = comdat any
@@ -33,6 +35,10 @@ $"??_C@_02DPKJAMEF@?$CFd?$AA@" = comdat any
 
 @"??_C@_03PMGGPEJJ@?$CFd?6?$AA@" = linkonce_odr dso_local unnamed_addr constant [4 x i8] c"%d\0A\00", comdat, align 1
 @"??_C@_02DPKJAMEF@?$CFd?$AA@" = linkonce_odr dso_local unnamed_addr constant [3 x i8] c"%d\00", comdat, align 1
+@.str = private unnamed_addr constant [44 x i8] c"runtime error: division by zero at line %d\0A\00", align 1
+@.str.1 = private unnamed_addr constant [42 x i8] c"runtime error: integer overflow at %s:%d\0A\00", align 1
+@.str.2 = private unnamed_addr constant [5 x i8] c"%lld\00", align 1
+@.str.3 = private unnamed_addr constant [6 x i8] c"%lld\0A\00", align 1
 @__local_stdio_printf_options._OptionsStorage = internal global i64 0, align 8
 @__local_stdio_scanf_options._OptionsStorage = internal global i64 0, align 8
 
@@ -161,6 +167,23 @@ define linkonce_odr dso_local i32 @scanf_s(ptr noundef %0, ...) #0 comdat {
   ret i32 %9
 }
 
+; Function Attrs: noinline nounwind optnone uwtable
+define dso_local i64 @pl_0_builtin_write_i64() #0 {
+  %1 = alloca i64, align 8
+  %2 = call i32 (ptr, ...) @scanf_s(ptr noundef @.str.2, ptr noundef %1)
+  %3 = load i64, ptr %1, align 8
+  ret i64 %3
+}
+
+; Function Attrs: noinline nounwind optnone uwtable
+define dso_local i32 @pl_0_builtin_println_i64(i64 noundef %0) #0 {
+  %2 = alloca i64, align 8
+  store i64 %0, ptr %2, align 8
+  %3 = load i64, ptr %2, align 8
+  %4 = call i32 (ptr, ...) @printf(ptr noundef @.str.3, i64 noundef %3)
+  ret i32 %4
+}
+
 ; Function Attrs: noinline nounwind optnone uwtable
 define dso_local i32 @pl_0_builtin_exit(i32 noundef %0) #0 {
   %2 = alloca i32, align 4
@@ -170,6 +193,50 @@ define dso_local i32 @pl_0_builtin_exit(i32 noundef %0) #0 {
   unreachable
 }
 
+; Function Attrs: noinline nounwind optnone uwtable
+define dso_local i32 @pl_0_builtin_divzero(i32 noundef %0) #0 {
+  %2 = alloca i32, align 4
+  store i32 %0, ptr %2, align 4
+  %3 = load i32, ptr %2, align 4
+  %4 = call ptr @__acrt_iob_func(i32 noundef 2)
+  %5 = call i32 (ptr, ptr, ...) @fprintf(ptr noundef %4, ptr noundef @.str, i32 noundef %3)
+  call void @exit(i32 noundef 1) #4
+  unreachable
+}
+
+; Function Attrs: noinline nounwind optnone uwtable
+define dso_local i32 @pl_0_builtin_overflow(ptr noundef %0, i32 noundef %1) #0 {
+  %3 = alloca i32, align 4
+  %4 = alloca ptr, align 8
+  store i32 %1, ptr %3, align 4
+  store ptr %0, ptr %4, align 8
+  %5 = load i32, ptr %3, align 4
+  %6 = load ptr, ptr %4, align 8
+  %7 = call ptr @__acrt_iob_func(i32 noundef 2)
+  %8 = call i32 (ptr, ptr, ...) @fprintf(ptr noundef %7, ptr noundef @.str.1, ptr noundef %6, i32 noundef %5)
+  call void @exit(i32 noundef 1) #4
+  unreachable
+}
+
+; Function Attrs: noinline nounwind optnone uwtable
+define linkonce_odr dso_local i32 @fprintf(ptr noundef %0, ptr noundef %1, ...) #0 comdat {
+  %3 = alloca ptr, align 8
+  %4 = alloca ptr, align 8
+  %5 = alloca i32, align 4
+  %6 = alloca ptr, align 8
+  store ptr %1, ptr %3, align 8
+  store ptr %0, ptr %4, align 8
+  call void @llvm.va_start(ptr %6)
+  %7 = load ptr, ptr %6, align 8
+  %8 = load ptr, ptr %3, align 8
+  %9 = load ptr, ptr %4, align 8
+  %10 = call i32 @_vfprintf_l(ptr noundef %9, ptr noundef %8, ptr noundef null, ptr noundef %7)
+  store i32 %10, ptr %5, align 4
+  call void @llvm.va_end(ptr %6)
+  %11 = load i32, ptr %5, align 4
+  ret i32 %11
+}
+
 ; Function Attrs: noreturn
 declare dso_local void @exit(i32 noundef) #1
 
@@ -18,17 +18,3 @@ func GetBuiltinLL(goos, goarch string) string {
 	}
 	return llBuiltin
 }
-
-const Header = `
-declare i32 @pl_0_builtin_exit(i32)
-declare i32 @pl_0_builtin_println(i32)
-declare i32 @pl_0_builtin_write()
-
-`
-
-const MainMain = `
-define i32 @main() {
-	call i32() @pl_0_main()
-	ret i32 0
-}
-`
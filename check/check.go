@@ -0,0 +1,273 @@
+package check
+
+import (
+	"fmt"
+	"pl0Compiler/ast"
+	"pl0Compiler/compiler"
+	"pl0Compiler/constant"
+	"pl0Compiler/lexer"
+	"pl0Compiler/token"
+	"sort"
+)
+
+// Info 记录检查得到的语义信息
+type Info struct {
+	Defs  map[*ast.Ident]*compiler.Object // 标识符声明处对应的对象
+	Uses  map[*ast.Ident]*compiler.Object // 标识符引用处对应的对象
+	Types map[ast.Expr]compiler.Type      // 表达式的类型
+
+	// Scopes 结点对应的作用域: 程序的顶层作用域, 过程的参数和局部变量,
+	// 以及 begin/if/while/repeat 语句的作用域
+	Scopes map[ast.Node]*compiler.Scope
+}
+
+// Checker 在代码生成之前对语法树做语义检查.
+type Checker struct {
+	program *ast.Program
+	info    *Info
+	scope   *compiler.Scope
+	modules map[*ast.Program]*compiler.Scope // 已检查的模块的顶层作用域
+	bits    int                              // 整数的位数, 数字和常量不能超出范围
+	errors  lexer.ErrorList
+}
+
+func NewChecker(info *Info) *Checker {
+	if info == nil {
+		info = new(Info)
+	}
+	if info.Defs == nil {
+		info.Defs = make(map[*ast.Ident]*compiler.Object)
+	}
+	if info.Uses == nil {
+		info.Uses = make(map[*ast.Ident]*compiler.Object)
+	}
+	if info.Types == nil {
+		info.Types = make(map[ast.Expr]compiler.Type)
+	}
+	if info.Scopes == nil {
+		info.Scopes = make(map[ast.Node]*compiler.Scope)
+	}
+	return &Checker{
+		info:    info,
+		scope:   compiler.NewScope(compiler.Universe),
+		modules: make(map[*ast.Program]*compiler.Scope),
+		bits:    32,
+	}
+}
+
+// SetIntWidth 设置整数的位数, bits 为 32 或 64, 默认为 32
+func (p *Checker) SetIntWidth(bits int) {
+	if bits != 32 && bits != 64 {
+		panic(fmt.Sprintf("invalid int width %d", bits))
+	}
+	p.bits = bits
+}
+
+// Check 检查程序, 返回的错误为 lexer.ErrorList. info 可以为 nil.
+func Check(program *ast.Program, info *Info) error {
+	return NewChecker(info).Check(program)
+}
+
+func (p *Checker) Check(program *ast.Program) error {
+	p.program = program
+	p.checkProgram(program)
+	p.errors.Sort()
+	return p.errors.Err()
+}
+
+func (p *Checker) errorf(pos token.Pos, format string, args ...interface{}) {
+	p.errors.Add(p.position(pos), fmt.Sprintf(format, args...))
+}
+
+func (p *Checker) position(pos token.Pos) token.Position {
+	return p.program.FileSet.Position(pos)
+}
+
+func (p *Checker) enterScope() {
+	p.scope = compiler.NewScope(p.scope)
+}
+
+// recordScope 把当前作用域记录为 node 的作用域
+func (p *Checker) recordScope(node ast.Node) {
+	p.info.Scopes[node] = p.scope
+}
+
+func (p *Checker) restoreScope(scope *compiler.Scope) {
+	p.scope = scope
+}
+
+// declare 在当前作用域中声明对象, 重复声明时报告错误
+func (p *Checker) declare(name string, pos token.Pos, kind compiler.ObjKind, node ast.Node) *compiler.Object {
+	obj := &compiler.Object{
+		Name: name,
+		Kind: kind,
+		Node: node,
+	}
+	if alt := p.scope.Insert(obj); alt != nil {
+		prev := p.declPos(alt)
+		if prev > pos {
+			// 变量和过程先于常量声明, 在源代码中靠后的声明处报告
+			pos, prev = prev, pos
+		}
+		if prev.IsValid() {
+			p.errorf(pos, "%s redeclared in this block, previous declaration at %s",
+				name, p.position(prev))
+		} else {
+			p.errorf(pos, "%s redeclared in this block", name)
+		}
+	}
+	return obj
+}
+
+// declareIdent 声明标识符并记录到 Info.Defs
+func (p *Checker) declareIdent(ident *ast.Ident, kind compiler.ObjKind, node ast.Node) *compiler.Object {
+	obj := p.declare(ident.Name, ident.NamePos, kind, node)
+	p.info.Defs[ident] = obj
+	return obj
+}
+
+// declPos 返回对象声明处标识符的位置
+func (p *Checker) declPos(obj *compiler.Object) token.Pos {
+	switch node := obj.Node.(type) {
+	case *ast.Ident:
+		return node.NamePos
+	case *ast.DefineStmt:
+		return node.Target.NamePos
+	case *ast.ProcDecl:
+		return node.NamePos
+	}
+	return token.NoPos
+}
+
+func (p *Checker) checkProgram(program *ast.Program) {
+	defer p.restoreScope(p.scope)
+	p.scope = p.checkDecls(program)
+
+	if program.Stmt != nil {
+		p.checkStmt(program.Stmt)
+	}
+}
+
+// checkDecls 检查程序的导入和顶层声明, 返回顶层作用域.
+// 导入的名字位于顶层作用域之外, 可以被程序自己的声明遮蔽.
+func (p *Checker) checkDecls(program *ast.Program) *compiler.Scope {
+	defer p.restoreScope(p.scope)
+	p.scope = p.checkImports(program)
+	p.enterScope()
+	p.recordScope(program)
+
+	for _, g := range program.Globals {
+		p.declareVar(g)
+	}
+	for _, fn := range program.Funcs {
+		p.declare(fn.Name, fn.NamePos, compiler.Proc, fn)
+	}
+	// 常量按声明顺序求值, 只能引用之前声明的常量
+	for _, c := range program.Const {
+		for _, def := range c.Definition {
+			p.checkConst(def)
+		}
+	}
+	for _, fn := range program.Funcs {
+		p.checkProcedure(fn)
+	}
+	return p.scope
+}
+
+// checkImports 返回包含全部导入名字的作用域
+func (p *Checker) checkImports(program *ast.Program) *compiler.Scope {
+	scope := compiler.NewScope(compiler.Universe)
+	for _, imp := range program.Imports {
+		if imp.Module == nil {
+			continue
+		}
+		objects := p.checkModule(imp.Module).Objects
+		names := make([]string, 0, len(objects))
+		for name := range objects {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			alt := scope.Insert(objects[name])
+			if alt == nil || alt == objects[name] {
+				continue
+			}
+			if prev := p.declPos(alt); prev.IsValid() {
+				p.errorf(imp.PathPos, "%s imported from %q redeclared, previous declaration at %s",
+					name, imp.Path, p.position(prev))
+			} else {
+				p.errorf(imp.PathPos, "%s imported from %q redeclared", name, imp.Path)
+			}
+		}
+	}
+	return scope
+}
+
+// checkModule 检查导入的模块, 每个模块只检查一次
+func (p *Checker) checkModule(module *ast.Program) *compiler.Scope {
+	if scope, ok := p.modules[module]; ok {
+		return scope
+	}
+	scope := p.checkDecls(module)
+	p.modules[module] = scope
+	return scope
+}
+
+func (p *Checker) checkConst(def *ast.DefineStmt) {
+	n := len(p.errors)
+	p.checkValue(def.Value)
+	obj := p.declareIdent(def.Target, compiler.Con, def)
+	if len(p.errors) != n {
+		return
+	}
+	value, err := constant.EvalWidth(def.Value, p.bits, p.constValue)
+	if err != nil {
+		e := err.(*constant.Error)
+		p.errorf(e.Pos, "const %s: %s", def.Target.Name, e.Msg)
+		return
+	}
+	obj.Value = value
+}
+
+// constValue 查找已声明常量的值
+func (p *Checker) constValue(name string) (int64, bool) {
+	_, obj := p.scope.Lookup(name)
+	if obj == nil || obj.Kind != compiler.Con {
+		return 0, false
+	}
+	return obj.Value, true
+}
+
+func (p *Checker) declareVar(decl *ast.VarDecl) {
+	for _, name := range decl.Names {
+		p.declareIdent(name, compiler.Var, name)
+	}
+}
+
+func (p *Checker) checkProcedure(fn *ast.ProcDecl) {
+	defer p.restoreScope(p.scope)
+	p.enterScope()
+
+	// args+body scope
+	p.enterScope()
+	p.recordScope(fn)
+	for _, arg := range fn.Params.List {
+		p.declareIdent(arg.Name, compiler.Param, arg.Name)
+	}
+	if fn.VarDecl != nil {
+		p.declareVar(fn.VarDecl)
+	}
+	for _, sub := range fn.Funcs {
+		p.declare(sub.Name, sub.NamePos, compiler.Proc, sub)
+	}
+	for _, sub := range fn.Funcs {
+		p.checkProcedure(sub)
+	}
+
+	if fn.Body != nil {
+		for _, x := range fn.Body.List {
+			p.checkStmt(x)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package check_test
+
+import (
+	"pl0Compiler/check"
+	"pl0Compiler/lexer"
+	"pl0Compiler/parser"
+	"pl0Compiler/token"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinArity(t *testing.T) {
+	tests := []struct {
+		src  string
+		errs []string
+	}{
+		{"begin call println(1); call exit(0); end.", nil},
+		{"begin call exit; end.", []string{"call to exit: want 1, got 0"}},
+		{"begin call println(1, 2); end.", []string{"call to println: want 1, got 2"}},
+	}
+	for _, tt := range tests {
+		program, err := parser.ParseFile(token.NewFileSet(), "test.pl", tt.src)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", tt.src, err)
+		}
+		var errs lexer.ErrorList
+		if err := check.Check(program, nil); err != nil {
+			errs = err.(lexer.ErrorList)
+		}
+		if len(errs) != len(tt.errs) {
+			t.Errorf("%s: got errors %v, want %q", tt.src, errs, tt.errs)
+			continue
+		}
+		for i, e := range errs {
+			if !strings.Contains(e.Error(), tt.errs[i]) {
+				t.Errorf("%s: error %q does not contain %q", tt.src, e, tt.errs[i])
+			}
+		}
+	}
+}
+
+// checkErrors 解析并检查 src, 返回全部错误的文本
+func checkErrors(t *testing.T, src string) []string {
+	t.Helper()
+	program, err := parser.ParseFile(token.NewFileSet(), "test.pl", src)
+	if err != nil {
+		t.Fatalf("%s: parse: %v", src, err)
+	}
+	var errs []string
+	if err := check.Check(program, nil); err != nil {
+		for _, e := range err.(lexer.ErrorList) {
+			errs = append(errs, e.Error())
+		}
+	}
+	return errs
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		errs []string
+	}{
+		// 未声明的名字
+		{"var x;\nbegin\n  x := y + 1;\nend.", []string{"test.pl:3:8: undefined: y"}},
+		{"begin\n  z := 1;\nend.", []string{"test.pl:2:3: undefined: z"}},
+		{"begin\n  call q;\nend.", []string{"test.pl:2:8: undefined: q"}},
+
+		// 给常量赋值
+		{"const c = 1;\nbegin\n  c := 2;\nend.", []string{"test.pl:3:3: cannot assign to constant c"}},
+		{"const c = 1;\nbegin\n  write c;\nend.", []string{"test.pl:3:9: cannot assign to constant c"}},
+
+		// call 不是过程的名字
+		{"var x;\nbegin\n  call x;\nend.", []string{"test.pl:3:8: cannot call var x"}},
+		{"const c = 1;\nbegin\n  call c;\nend.", []string{"test.pl:3:8: cannot call const c"}},
+
+		// 用户过程的参数个数
+		{"procedure p(a, b);;\nbegin\n  call p(1);\nend.", []string{"test.pl:3:8: wrong number of arguments in call to p: want 2, got 1"}},
+		{"procedure p;;\nbegin\n  call p(1, 2);\nend.", []string{"test.pl:3:8: wrong number of arguments in call to p: want 0, got 2"}},
+
+		// 同一作用域中重复声明
+		{"var x, x;\nbegin\nend.", []string{"test.pl:1:8: x redeclared in this block, previous declaration at test.pl:1:5"}},
+		{"const x = 1;\nvar x;\nbegin\nend.", []string{"test.pl:2:5: x redeclared in this block, previous declaration at test.pl:1:7"}},
+		{"var p;\nprocedure p;;\nbegin\nend.", []string{"test.pl:2:11: p redeclared in this block, previous declaration at test.pl:1:5"}},
+		{"procedure p(a, a);;\nbegin\nend.", []string{"test.pl:1:16: a redeclared in this block, previous declaration at test.pl:1:13"}},
+
+		// 内层作用域可以遮蔽外层的声明
+		{"var x;\nprocedure p;\nvar x;\nbegin\n  x := 1;\nend;\nbegin\n  call p;\nend.", nil},
+	}
+	for _, tt := range tests {
+		errs := checkErrors(t, tt.src)
+		if strings.Join(errs, "\n") != strings.Join(tt.errs, "\n") {
+			t.Errorf("%q:\ngot  %q\nwant %q", tt.src, errs, tt.errs)
+		}
+	}
+}
+
+// TestTypes 检查条件和整数不能互相代替
+func TestTypes(t *testing.T) {
+	tests := []struct {
+		src  string
+		errs []string
+	}{
+		// 条件用作整数
+		{"var x, a;\nbegin\n  x := a < 1;\nend.", []string{"test.pl:3:8: condition used as value"}},
+		{"var x, a;\nbegin\n  x := (odd a);\nend.", []string{"test.pl:3:8: condition used as value"}},
+		{"var x, a;\nbegin\n  x := 1 + (a = 2);\nend.", []string{"test.pl:3:12: invalid operand for +: condition"}},
+		{"var a;\nbegin\n  call println(a > 0);\nend.", []string{"test.pl:3:16: condition used as value"}},
+		{"const c = 1 < 2;\nbegin\nend.", []string{"test.pl:1:11: condition used as value"}},
+
+		// 整数用作条件
+		{"var x;\nbegin\n  if x then x := 1;\nend.", []string{"test.pl:3:6: integer used as condition"}},
+		{"var x;\nbegin\n  while x + 1 do x := 0;\nend.", []string{"test.pl:3:9: integer used as condition"}},
+		{"var x;\nbegin\n  repeat x := 0; until x;\nend.", []string{"test.pl:3:24: integer used as condition"}},
+		{"var x;\nbegin\n  if odd (x > 0) then x := 1;\nend.", []string{"test.pl:3:10: invalid operand for odd: condition"}},
+
+		// 条件可以与整数比较相等, 比较的结果是条件
+		{"var x, a;\nbegin\n  if (a < 1) = x then x := 1;\n  if (a < 1) <> (x > 2) then x := 2;\nend.", nil},
+	}
+	for _, tt := range tests {
+		errs := checkErrors(t, tt.src)
+		if strings.Join(errs, "\n") != strings.Join(tt.errs, "\n") {
+			t.Errorf("%q:\ngot  %q\nwant %q", tt.src, errs, tt.errs)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package check
+
+import (
+	"fmt"
+	"pl0Compiler/ast"
+	"pl0Compiler/compiler"
+	"pl0Compiler/constant"
+	"pl0Compiler/token"
+)
+
+// checkExpr 检查表达式并返回其类型, 类型记录到 Info.Types
+func (p *Checker) checkExpr(expr ast.Expr) compiler.Type {
+	typ := p.exprType(expr)
+	p.info.Types[expr] = typ
+	return typ
+}
+
+func (p *Checker) exprType(expr ast.Expr) compiler.Type {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		obj := p.resolve(expr)
+		if obj != nil && obj.Kind == compiler.Proc {
+			p.errorf(expr.NamePos, "procedure %s used as value", expr.Name)
+			return compiler.Invalid
+		}
+		return compiler.Int
+	case *ast.Number:
+		if !constant.Fits(int64(expr.Value), p.bits) {
+			p.errorf(expr.ValuePos, "constant %d overflows %d-bit integer", expr.Value, p.bits)
+		}
+		return compiler.Int
+	case *ast.BinaryExpr:
+		x := p.checkExpr(expr.X)
+		y := p.checkExpr(expr.Y)
+		if x == compiler.Invalid || y == compiler.Invalid {
+			return compiler.Invalid
+		}
+		switch expr.Op {
+		case token.EQL, token.NEQ:
+			// 条件与整数可以比较相等, 条件按 0/1 参与比较
+			return compiler.Bool
+		case token.LSS, token.LEQ, token.GTR, token.GEQ:
+			if !p.expect(expr.X, x, compiler.Int, expr.Op) || !p.expect(expr.Y, y, compiler.Int, expr.Op) {
+				return compiler.Invalid
+			}
+			return compiler.Bool
+		default:
+			if !p.expect(expr.X, x, compiler.Int, expr.Op) || !p.expect(expr.Y, y, compiler.Int, expr.Op) {
+				return compiler.Invalid
+			}
+			return compiler.Int
+		}
+	case *ast.UnaryExpr:
+		x := p.checkExpr(expr.X)
+		if x == compiler.Invalid || !p.expect(expr.X, x, compiler.Int, expr.Op) {
+			return compiler.Invalid
+		}
+		if expr.Op == token.ODD {
+			return compiler.Bool
+		}
+		return compiler.Int
+	case *ast.ParenExpr:
+		return p.checkExpr(expr.X)
+
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
+	}
+}
+
+// expect 检查运算符 op 的操作数类型
+func (p *Checker) expect(expr ast.Expr, got, want compiler.Type, op token.TokenType) bool {
+	if got != want {
+		p.errorf(expr.Pos(), "invalid operand for %s: %s", op, got)
+		return false
+	}
+	return true
+}
+
+// checkValue 检查用作值的表达式, 不允许使用条件
+func (p *Checker) checkValue(expr ast.Expr) {
+	if typ := p.checkExpr(expr); typ == compiler.Bool {
+		p.errorf(expr.Pos(), "condition used as value")
+	}
+}
+
+// checkCond 检查用作条件的表达式, 不允许使用整数
+func (p *Checker) checkCond(expr ast.Expr) {
+	if typ := p.checkExpr(expr); typ == compiler.Int {
+		p.errorf(expr.Pos(), "integer used as condition")
+	}
+}
+
+// resolve 在作用域中查找标识符, 未定义时报告错误并返回 nil
+func (p *Checker) resolve(ident *ast.Ident) *compiler.Object {
+	_, obj := p.scope.Lookup(ident.Name)
+	if obj == nil {
+		p.errorf(ident.NamePos, "undefined: %s", ident.Name)
+		return nil
+	}
+	p.info.Uses[ident] = obj
+	return obj
+}
@@ -0,0 +1,108 @@
+package check
+
+import (
+	"fmt"
+	"pl0Compiler/ast"
+	"pl0Compiler/compiler"
+	"pl0Compiler/token"
+)
+
+func (p *Checker) checkStmt(stmt ast.Stmt) {
+	switch stmt := stmt.(type) {
+	case nil:
+		// 空语句
+	case *ast.VarDecl:
+		p.declareVar(stmt)
+	case *ast.AssignStmt:
+		p.checkValue(stmt.Value)
+		p.checkTarget(stmt.Target)
+	case *ast.IfStmt:
+		defer p.restoreScope(p.scope)
+		p.enterScope()
+		p.recordScope(stmt)
+
+		p.checkCond(stmt.Cond)
+		p.checkStmt(stmt.Body)
+		if stmt.Else != nil {
+			p.checkStmt(stmt.Else)
+		}
+	case *ast.WhileStmt:
+		defer p.restoreScope(p.scope)
+		p.enterScope()
+		p.recordScope(stmt)
+
+		p.checkCond(stmt.Cond)
+		p.checkStmt(stmt.Body)
+	case *ast.RepeatStmt:
+		defer p.restoreScope(p.scope)
+		p.enterScope()
+		p.recordScope(stmt)
+
+		p.checkStmt(stmt.Body)
+		p.checkCond(stmt.Cond)
+	case *ast.BlockStmt:
+		defer p.restoreScope(p.scope)
+		p.enterScope()
+		p.recordScope(stmt)
+
+		for _, x := range stmt.List {
+			p.checkStmt(x)
+		}
+	case *ast.ExprStmt:
+		p.checkExpr(stmt.X)
+	case *ast.CallStmt:
+		p.checkStmtCall(stmt)
+	case *ast.IOStmt:
+		switch stmt.Type {
+		case token.READ:
+			for _, param := range stmt.Params.List {
+				p.checkValue(param.Name)
+			}
+		case token.WRITE:
+			for _, param := range stmt.Params.List {
+				p.checkTarget(param.Name)
+			}
+		}
+
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", stmt))
+	}
+}
+
+// checkTarget 检查赋值的目标必须是变量或参数
+func (p *Checker) checkTarget(ident *ast.Ident) {
+	obj := p.resolve(ident)
+	if obj == nil {
+		return
+	}
+	switch obj.Kind {
+	case compiler.Var, compiler.Param:
+	case compiler.Con:
+		p.errorf(ident.NamePos, "cannot assign to constant %s", ident.Name)
+	default:
+		p.errorf(ident.NamePos, "cannot assign to %s %s", obj.Kind, ident.Name)
+	}
+}
+
+func (p *Checker) checkStmtCall(stmt *ast.CallStmt) {
+	for _, arg := range stmt.Args {
+		p.checkValue(arg)
+	}
+
+	obj := p.resolve(stmt.ProcedureName)
+	if obj == nil {
+		return
+	}
+	if obj.Kind != compiler.Proc {
+		p.errorf(stmt.ProcedureName.NamePos, "cannot call %s %s", obj.Kind, obj.Name)
+		return
+	}
+	want := obj.NumParams
+	if fn, ok := obj.Node.(*ast.ProcDecl); ok {
+		want = len(fn.Params.List)
+	}
+	if got := len(stmt.Args); want != got {
+		p.errorf(stmt.ProcedureName.NamePos, "wrong number of arguments in call to %s: want %d, got %d",
+			obj.Name, want, got)
+	}
+}
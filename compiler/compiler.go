@@ -1,35 +1,103 @@
 package compiler
 
 import (
-	"bytes"
 	"fmt"
-	"io"
 	"pl0Compiler/ast"
-	"pl0Compiler/builtin"
+	"pl0Compiler/constant"
+	"pl0Compiler/ir"
 	"pl0Compiler/token"
 )
 
+// Option 编译选项
+type Option struct {
+	Types         map[ast.Expr]Type // 表达式的类型, 通常由 check 包计算
+	CheckDiv      bool              // 除数为零或最小的整数除以 -1 时报告所在的位置并退出, 而不是由硬件异常终止
+	CheckOverflow bool              // +, -, * 和取负溢出时报告所在的文件和行并退出, 而不是回绕
+	IntWidth      int               // 整数的位数, 32 或 64, 为 0 时是 32
+}
+
 type Compiler struct {
 	program *ast.Program
+	types   map[ast.Expr]Type
 	scope   *Scope
-	nextId  int
+	frame   *procFrame
+	modules map[*ast.Program]*Scope // 已生成的模块的顶层作用域
+	module  string                  // 正在生成的模块名
+	slots   map[*Object]slot        // 保存在活动记录中的参数
+	parents map[*Object]*procFrame  // 嵌套过程所在的外层过程
+
+	m        *ir.Module
+	b        *ir.Builder
+	values   map[*Object]ir.Value // 变量和常量的地址
+	funcs    map[*Object]*ir.Func // 过程对应的函数
+	builtins map[string]*ir.Func  // 运行时库和 LLVM 的内建函数, 内置过程以 pl/0 中的名字为键
+
+	intType  *ir.IntType           // pl/0 的整数类型
+	checkDiv bool                  // 见 Option.CheckDiv
+	overflow bool                  // 见 Option.CheckOverflow
+	files    map[string]*ir.Global // 报告溢出时使用的文件名常量
+}
+
+// procFrame 过程的活动记录. 过程的参数保存在活动记录中,
+// 嵌套的过程通过活动记录第 0 个字段中的静态链访问外层过程的活动记录.
+type procFrame struct {
+	name  string         // 过程的名字, 不含 '@'
+	typ   *ir.StructType // 活动记录的结构体类型
+	ptr   ir.Value       // 过程中指向自己的活动记录的指针
+	outer *procFrame     // 外层过程, 顶层过程为 nil
+}
+
+// slot 对象在活动记录中的位置
+type slot struct {
+	frame *procFrame
+	field int
 }
 
-func NewCompiler() *Compiler {
-	return &Compiler{
-		scope: NewScope(Universe),
+func NewCompiler(opt *Option) *Compiler {
+	p := &Compiler{
+		scope:   NewScope(Universe),
+		modules: make(map[*ast.Program]*Scope),
+		slots:   make(map[*Object]slot),
+		parents: make(map[*Object]*procFrame),
+		values:  make(map[*Object]ir.Value),
+		funcs:   make(map[*Object]*ir.Func),
+		files:   make(map[string]*ir.Global),
+		intType: ir.I32,
 	}
+	if opt != nil {
+		p.types = opt.Types
+		p.checkDiv = opt.CheckDiv
+		p.overflow = opt.CheckOverflow
+		switch opt.IntWidth {
+		case 0, 32:
+		case 64:
+			p.intType = ir.I64
+		default:
+			panic(fmt.Sprintf("invalid int width %d", opt.IntWidth))
+		}
+	}
+	return p
 }
 
+// Compile 编译程序并返回 .ll 文本, 生成的 IR 不合法时 panic
 func (p *Compiler) Compile(program *ast.Program) string {
-	var buf bytes.Buffer
+	m := p.CompileModule(program)
+	if err := ir.Verify(m); err != nil {
+		panic(fmt.Sprintf("invalid ir:\n%v", err))
+	}
+	return m.String()
+}
 
+// CompileModule 把程序编译为 IR 模块, 不做校验
+func (p *Compiler) CompileModule(program *ast.Program) *ir.Module {
 	p.program = program
+	p.m = ir.NewModule()
+	p.b = ir.NewBuilder()
 
-	p.genHeader(&buf, program)
-	p.compileProgram(&buf, program)
+	p.genHeader(program)
+	p.compileProgram(program)
 
-	return buf.String()
+	return p.m
 }
 
 func (p *Compiler) enterScope() {
@@ -44,468 +112,710 @@ func (p *Compiler) restoreScope(scope *Scope) {
 	p.scope = scope
 }
 
-func (p *Compiler) genHeader(w io.Writer, program *ast.Program) {
-	_, _ = fmt.Fprintf(w, "; program name %s\n", program.FileName)
-	_, _ = fmt.Fprint(w, builtin.Header)
+// genHeader 声明运行时库中的函数
+func (p *Compiler) genHeader(program *ast.Program) {
+	p.m.Comment = "program name " + program.FileName
+	// 64 位整数使用 _i64 后缀的输入输出函数, exit 的参数总是 i32
+	suffix := ""
+	if p.intType.Bits == 64 {
+		suffix = "_i64"
+	}
+	p.builtins = map[string]*ir.Func{
+		"exit":    p.m.NewFunc("pl_0_builtin_exit", ir.I32, ir.NewParam("", ir.I32)),
+		"println": p.m.NewFunc("pl_0_builtin_println"+suffix, ir.I32, ir.NewParam("", p.intType)),
+		"write":   p.m.NewFunc("pl_0_builtin_write"+suffix, p.intType),
+	}
+	if p.checkDiv {
+		p.builtins["divzero"] = p.m.NewFunc("pl_0_builtin_divzero", ir.I32, ir.NewParam("", ir.I32))
+	}
+	if p.checkDiv || p.overflow {
+		p.builtins["overflow"] = p.m.NewFunc("pl_0_builtin_overflow", ir.I32,
+			ir.NewParam("", ir.NewPointer(ir.I8)), ir.NewParam("", ir.I32))
+	}
+	if p.overflow {
+		// llvm.sadd.with.overflow.i32 等内建函数返回 { 结果, 是否溢出 }
+		for _, op := range []string{"sadd", "ssub", "smul"} {
+			name := fmt.Sprintf("llvm.%s.with.overflow.%s", op, p.intType)
+			p.builtins[op] = p.m.NewFunc(name, ir.NewStruct(p.intType, ir.I1),
+				ir.NewParam("", p.intType), ir.NewParam("", p.intType))
+		}
+	}
 }
 
-func (p *Compiler) genMain(w io.Writer, program *ast.Program) {
-	_, _ = fmt.Fprintf(w, "define i32 @pl_0_main() {\n")
-	p.compileStmt(w, program.Stmt)
-	_, _ = fmt.Fprintf(w, "\tret i32 0\n}\n")
-	_, _ = fmt.Fprintf(w, builtin.MainMain)
+func (p *Compiler) genMain(program *ast.Program) {
+	fn := p.m.NewFunc("pl_0_main", ir.I32)
+	p.b.SetInsertPoint(fn.NewBlock("entry"))
+	if program.Stmt != nil {
+		p.compileStmt(program.Stmt)
+	}
+	p.ret()
+
+	main := p.m.NewFunc("main", ir.I32)
+	p.b.SetInsertPoint(main.NewBlock("entry"))
+	p.b.NewCall(fn)
+	p.b.NewRet(ir.NewInt(ir.I32, 0))
 }
 
-func (p *Compiler) compileProgram(w io.Writer, program *ast.Program) {
+func (p *Compiler) compileProgram(program *ast.Program) {
 	defer p.restoreScope(p.scope)
+	p.scope = p.compileDecls(program)
+
+	p.genMain(program)
+}
+
+// compileImports 生成导入的模块, 返回包含全部导入名字的作用域
+func (p *Compiler) compileImports(program *ast.Program) *Scope {
+	scope := NewScope(Universe)
+	for _, imp := range program.Imports {
+		for _, obj := range p.compileModule(imp.Module).Objects {
+			scope.Insert(obj)
+		}
+	}
+	return scope
+}
+
+// compileModule 生成导入的模块, 每个模块只生成一次
+func (p *Compiler) compileModule(module *ast.Program) *Scope {
+	if scope, ok := p.modules[module]; ok {
+		return scope
+	}
+	scope := p.compileDecls(module)
+	p.modules[module] = scope
+	return scope
+}
+
+// globalName 返回全局名字, 模块中的名字为 @pl_0_<模块>$<名字>
+func (p *Compiler) globalName(name string) string {
+	if p.module != "" {
+		return fmt.Sprintf("@pl_0_%s$%s", p.module, name)
+	}
+	return fmt.Sprintf("@pl_0_%s", name)
+}
+
+// compileDecls 生成程序的全局变量, 常量和过程, 返回顶层作用域
+func (p *Compiler) compileDecls(program *ast.Program) *Scope {
+	defer p.restoreScope(p.scope)
+	p.scope = p.compileImports(program)
 	p.enterScope()
 
+	defer func(module string) { p.module = module }(p.module)
+	p.module = program.Name
+
 	for _, g := range program.Globals {
 		for _, name := range g.Names {
-			var mangledName = fmt.Sprintf("@pl_0_%s", name.Name)
-			p.scope.Insert(&Object{
+			var mangledName = p.globalName(name.Name)
+			obj := &Object{
 				Name:        name.Name,
 				MangledName: mangledName,
+				Kind:        Var,
 				Node:        name,
-			})
-			_, _ = fmt.Fprintf(w, "%s = dso_local global i32 0, align 4\n", mangledName)
+			}
+			p.scope.Insert(obj)
+			g := p.m.NewGlobal(mangledName[1:], p.int(0))
+			g.Align = p.align()
+			p.values[obj] = g
 		}
 	}
-	if len(program.Globals) != 0 {
-		_, _ = fmt.Fprintln(w)
-	}
 
 	for _, c := range program.Const {
 		for _, name := range c.Definition {
-			var mangledName = fmt.Sprintf("@pl_0_%s", name.Target.Name)
-			p.scope.Insert(&Object{
+			var mangledName = p.globalName(name.Target.Name)
+			value, err := constant.EvalWidth(name.Value, p.intType.Bits, p.constValue)
+			if err != nil {
+				panic(fmt.Sprintf("const %s: %v", name.Target.Name, err))
+			}
+			obj := &Object{
 				Name:        name.Target.Name,
 				MangledName: mangledName,
+				Kind:        Con,
+				Value:       value,
 				Node:        name,
-			})
-			_, _ = fmt.Fprintf(w, "%s = dso_local constant i32 %d, align 4\n",
-				mangledName, name.Value.(*ast.Number).Value)
+			}
+			p.scope.Insert(obj)
+			g := p.m.NewConstant(mangledName[1:], p.int(value))
+			g.Align = p.align()
+			p.values[obj] = g
 		}
 	}
-	if len(program.Const) != 0 {
-		_, _ = fmt.Fprintln(w)
+
+	p.compileProcedures(program.Funcs)
+	return p.scope
+}
+
+// constValue 查找已声明常量的值
+func (p *Compiler) constValue(name string) (int64, bool) {
+	_, obj := p.scope.Lookup(name)
+	if obj == nil || obj.Kind != Con {
+		return 0, false
 	}
+	return obj.Value, true
+}
 
-	for _, fn := range program.Funcs {
-		var mangledName = fmt.Sprintf("@pl_0_%s", fn.Name)
-		p.scope.Insert(&Object{
+// compileProcedures 在当前作用域中声明并生成一组同层的过程
+func (p *Compiler) compileProcedures(funcs []*ast.ProcDecl) {
+	var objs []*Object
+	for _, fn := range funcs {
+		var mangledName = p.globalName(fn.Name)
+		if p.frame != nil {
+			mangledName = fmt.Sprintf("@%s.%s", p.frame.name, fn.Name)
+		}
+		obj := &Object{
 			Name:        fn.Name,
 			MangledName: mangledName,
+			Kind:        Proc,
 			Node:        fn,
-		})
-	}
+		}
+		p.scope.Insert(obj)
+		p.parents[obj] = p.frame
+		objs = append(objs, obj)
 
-	for _, fn := range program.Funcs {
-		p.compileProcedure(w, fn)
+		// 第一个参数是静态链
+		params := []*ir.Param{ir.NewParam("static_link", ir.NewPointer(ir.I8))}
+		for i, arg := range fn.Params.List {
+			name := fmt.Sprintf("local_%s.pos.%d.arg%d", arg.Name.Name, arg.Name.NamePos, i)
+			params = append(params, ir.NewParam(name, p.intType))
+		}
+		p.funcs[obj] = p.m.NewFunc(mangledName[1:], ir.I32, params...)
 	}
 
-	p.genMain(w, program)
+	for i, fn := range funcs {
+		p.compileProcedure(fn, objs[i])
+	}
 }
 
-func (p *Compiler) compileProcedure(w io.Writer, fn *ast.ProcDecl) {
+func (p *Compiler) compileProcedure(fn *ast.ProcDecl, obj *Object) {
 	defer p.restoreScope(p.scope)
 	p.enterScope()
 
-	// args
-	var argNameList []string
-	for _, arg := range fn.Params.List {
-		var mangledName = fmt.Sprintf("%%local_%s.pos.%d", arg.Name.Name, arg.Name.NamePos)
-		argNameList = append(argNameList, mangledName)
-	}
-
 	if fn.Body == nil {
-		_, _ = fmt.Fprintf(w, "declare i32 @pl_0_%s()\n", fn.Name)
+		// 没有过程体的过程只声明, 由其它文件定义
 		return
 	}
 
-	_, _ = fmt.Fprintf(w, "define i32 @pl_0_%s(", fn.Name)
-	var first = true
-	for i, argRegName := range argNameList {
-		if first {
-			first = false
-			_, _ = fmt.Fprintf(w, "i32 noundef %s.arg%d", argRegName, i)
-			continue
-		}
-		_, _ = fmt.Fprintf(w, ", i32 noundef %s.arg%d", argRegName, i)
+	frame := &procFrame{
+		name:  obj.MangledName[1:],
+		outer: p.frame,
 	}
-	_, _ = fmt.Fprintf(w, ") {\n")
-
-	// proc body
-	func() {
-		// args+body scope
-		defer p.restoreScope(p.scope)
-		p.enterScope()
+	defer func(outer *procFrame) { p.frame = outer }(p.frame)
 
-		// args
-		for i, arg := range fn.Params.List {
-			var argRegName = fmt.Sprintf("%s.arg%d", argNameList[i], i)
-			var mangledName = argNameList[i]
-			p.scope.Insert(&Object{
-				Name:        arg.Name.Name,
-				MangledName: mangledName,
-				Node:        fn,
-			})
+	// args+body scope
+	p.enterScope()
 
-			_, _ = fmt.Fprintf(w, "\t%s = alloca i32, align 4\n", mangledName)
-			_, _ = fmt.Fprintf(w, "\tstore i32 %s, i32* %s\n", argRegName, mangledName)
+	// 活动记录: 静态链, 参数, 局部变量
+	fields := []ir.Type{ir.NewPointer(ir.I8)}
+	var argObjs, localObjs []*Object
+	for _, arg := range fn.Params.List {
+		argObj := &Object{
+			Name:        arg.Name.Name,
+			MangledName: fmt.Sprintf("%%local_%s.pos.%d", arg.Name.Name, arg.Name.NamePos),
+			Kind:        Param,
+			Node:        fn,
 		}
+		p.scope.Insert(argObj)
+		p.slots[argObj] = slot{frame: frame, field: len(fields)}
+		fields = append(fields, p.intType)
+		argObjs = append(argObjs, argObj)
+	}
 
-		// body
-		for _, x := range fn.Body.List {
-			p.compileStmt(w, x)
+	if fn.VarDecl != nil {
+		for _, name := range fn.VarDecl.Names {
+			localObj := &Object{
+				Name:        name.Name,
+				MangledName: fmt.Sprintf("%%local_%s.pos.%d", name.Name, name.NamePos),
+				Kind:        Var,
+				Node:        fn.VarDecl,
+			}
+			p.scope.Insert(localObj)
+			p.slots[localObj] = slot{frame: frame, field: len(fields)}
+			fields = append(fields, p.intType)
+			localObjs = append(localObjs, localObj)
 		}
-	}()
+	}
+	frame.typ = p.m.NewType("frame."+frame.name, fields...)
+
+	// 嵌套的过程先于外层过程生成
+	p.frame = frame
+	p.compileProcedures(fn.Funcs)
+
+	f := p.funcs[obj]
+	p.b.SetInsertPoint(f.NewBlock("entry"))
+
+	alloca := p.b.NewAlloca(frame.typ, 8)
+	alloca.SetName("frame")
+	frame.ptr = alloca
+	link := p.b.NewGEP(frame.typ, frame.ptr, p.i32(0), p.i32(0))
+	link.SetName("frame.link")
+	p.b.NewStore(f.Params[0], link, 8)
+
+	// 参数和局部变量
+	for i, argObj := range argObjs {
+		ptr := p.b.NewGEP(frame.typ, frame.ptr, p.i32(0), p.i32(int64(i+1)))
+		ptr.SetName(argObj.MangledName[1:])
+		p.b.NewStore(f.Params[i+1], ptr, p.align())
+		p.values[argObj] = ptr
+	}
+	for i, localObj := range localObjs {
+		ptr := p.b.NewGEP(frame.typ, frame.ptr, p.i32(0), p.i32(int64(len(argObjs)+i+1)))
+		ptr.SetName(localObj.MangledName[1:])
+		p.b.NewStore(p.int(0), ptr, p.align())
+		p.values[localObj] = ptr
+	}
+
+	// body
+	for _, x := range fn.Body.List {
+		p.compileStmt(x)
+	}
+
+	p.ret()
+}
+
+// br 跳转到 target. 当前基本块已经结束时不再添加分支, 保证每个基本块只有一条终结指令.
+func (p *Compiler) br(target *ir.Block) {
+	if p.b.Block().Term() == nil {
+		p.b.NewBr(target)
+	}
+}
+
+// ret 从过程返回 0, 当前基本块已经结束时不再添加
+func (p *Compiler) ret() {
+	if p.b.Block().Term() == nil {
+		p.b.NewRet(p.i32(0))
+	}
+}
 
-	//p.compileStmt(w, fn.Body)
-	_, _ = fmt.Fprintln(w, "\tret i32 0")
-	_, _ = fmt.Fprintln(w, "}")
+// int 返回 pl/0 整数类型的常量
+func (p *Compiler) int(x int64) ir.Value {
+	return ir.NewInt(p.intType, x)
 }
 
-func (p *Compiler) compileStmt(w io.Writer, stmt ast.Stmt) {
+// i32 返回 i32 常量, 用于结构体下标, 行号和函数的返回值
+func (p *Compiler) i32(x int64) ir.Value {
+	return ir.NewInt(ir.I32, x)
+}
+
+// align 返回 pl/0 整数的对齐字节数
+func (p *Compiler) align() int {
+	return p.intType.Bits / 8
+}
+
+// framePtr 沿静态链找到外层过程 target 的活动记录, 返回 target.typ* 类型的值
+func (p *Compiler) framePtr(target *procFrame) ir.Value {
+	ptr := p.frame.ptr
+	for frame := p.frame; frame != target; frame = frame.outer {
+		linkPtr := p.b.NewGEP(frame.typ, ptr, p.i32(0), p.i32(0))
+		link := p.b.NewLoad(linkPtr, 8)
+		ptr = p.b.NewBitCast(link, ir.NewPointer(frame.outer.typ))
+	}
+	return ptr
+}
+
+// varPtr 返回对象的地址, 外层过程的参数需要经过静态链访问
+func (p *Compiler) varPtr(obj *Object) ir.Value {
+	s, ok := p.slots[obj]
+	if !ok || s.frame == p.frame {
+		return p.values[obj]
+	}
+	frame := p.framePtr(s.frame)
+	return p.b.NewGEP(s.frame.typ, frame, p.i32(0), p.i32(int64(s.field)))
+}
+
+func (p *Compiler) compileStmt(stmt ast.Stmt) {
 	switch stmt := stmt.(type) {
+	case nil:
+		// 空语句, 例如 if c then ;
 	case *ast.VarDecl:
 		for _, name := range stmt.Names {
 
 			var mangledName = fmt.Sprintf("%%local_%s.pos.%d", name.Name, stmt.VarPos)
-			p.scope.Insert(&Object{
+			obj := &Object{
 				Name:        name.Name,
 				MangledName: mangledName,
+				Kind:        Var,
 				Node:        stmt,
-			})
+			}
+			p.scope.Insert(obj)
 
-			_, _ = fmt.Fprintf(w, "\t%s = alloca i32, align 4\n", mangledName)
-			_, _ = fmt.Fprintf(w, "\tstore i32 0, i32* %s\n", mangledName)
+			ptr := p.b.NewAlloca(p.intType, p.align())
+			ptr.SetName(mangledName[1:])
+			p.b.NewStore(p.int(0), ptr, p.align())
+			p.values[obj] = ptr
 		}
 
 	case *ast.AssignStmt:
-		p.compileStmtAssign(w, stmt)
+		p.compileStmtAssign(stmt)
 	case *ast.IfStmt:
-		p.compileStmtIf(w, stmt)
+		p.compileStmtIf(stmt)
 	case *ast.WhileStmt:
-		p.compileStmtWhile(w, stmt)
+		p.compileStmtWhile(stmt)
 	case *ast.RepeatStmt:
-		p.compileStmtRepeat(w, stmt)
+		p.compileStmtRepeat(stmt)
 	case *ast.BlockStmt:
 		defer p.restoreScope(p.scope)
 		p.enterScope()
 
 		for _, x := range stmt.List {
-			p.compileStmt(w, x)
+			p.compileStmt(x)
 		}
 	case *ast.ExprStmt:
-		p.compileExpr(w, stmt.X)
+		p.compileExpr(stmt.X)
 	case *ast.CallStmt:
-		p.compileStmtCall(w, stmt)
+		p.compileStmtCall(stmt)
 	case *ast.IOStmt:
-		p.compileIOStmt(w, stmt)
+		p.compileIOStmt(stmt)
 
 	default:
 		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", stmt))
 	}
 }
 
-func (p *Compiler) compileStmtAssign(w io.Writer, stmt *ast.AssignStmt) {
-	var name string
-	valueName := p.compileExpr(w, stmt.Value)
-	if _, obj := p.scope.Lookup(stmt.Target.Name); obj != nil {
-		name = obj.MangledName
-	} else {
+func (p *Compiler) compileStmtAssign(stmt *ast.AssignStmt) {
+	value := p.compileValue(stmt.Value)
+	_, obj := p.scope.Lookup(stmt.Target.Name)
+	if obj == nil {
 		panic(fmt.Sprintf("var %s undefined", stmt.Target.Name))
 	}
-	_, _ = fmt.Fprintf(
-		w, "\tstore i32 %s, i32* %s\n",
-		valueName, name,
-	)
+	p.b.NewStore(value, p.varPtr(obj), p.align())
 }
 
-func (p *Compiler) compileStmtIf(w io.Writer, stmt *ast.IfStmt) {
+func (p *Compiler) compileStmtIf(stmt *ast.IfStmt) {
 	defer p.restoreScope(p.scope)
 	p.enterScope()
 
+	f := p.b.Func()
 	ifPos := fmt.Sprintf("%d", p.posLine(stmt.If))
-	ifCond := p.genLabelId("if.cond.line" + ifPos)
-	ifBody := p.genLabelId("if.body.line" + ifPos)
-	ifElse := p.genLabelId("if.else.line" + ifPos)
-	ifEnd := p.genLabelId("if.end.line" + ifPos)
+	ifCond := f.NewBlock("if.cond.line" + ifPos)
+	ifBody := f.NewBlock("if.body.line" + ifPos)
+	var ifElse *ir.Block
+	if stmt.Else != nil {
+		ifElse = f.NewBlock("if.else.line" + ifPos)
+	}
+	ifEnd := f.NewBlock("if.end.line" + ifPos)
 
+	p.br(ifCond)
+
+	// if.cond, 没有 else 时条件不成立直接跳到 if.end
+	p.b.SetInsertPoint(ifCond)
+	if ifElse != nil {
+		p.b.NewCondBr(p.compileCond(stmt.Cond), ifBody, ifElse)
+	} else {
+		p.b.NewCondBr(p.compileCond(stmt.Cond), ifBody, ifEnd)
+	}
+
+	// if.body
 	func() {
 		defer p.restoreScope(p.scope)
 		p.enterScope()
 
-		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifCond)
+		p.b.SetInsertPoint(ifBody)
+		p.compileStmt(stmt.Body)
+		p.br(ifEnd)
+	}()
 
-		// if.cond
-		{
-			_, _ = fmt.Fprintf(w, "\n%s:\n", ifCond)
-			condValue := p.compileExpr(w, stmt.Cond)
-			if stmt.Else != nil {
-				_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", condValue, ifBody, ifElse)
-			} else {
-				_, _ = fmt.Fprintf(w, "\tbr i1 %s, label %%%s, label %%%s\n", condValue, ifBody, ifEnd)
-			}
-		}
+	// if.else
+	if stmt.Else != nil {
+		defer p.restoreScope(p.scope)
+		p.enterScope()
 
-		// if.body
-		func() {
-			defer p.restoreScope(p.scope)
-			p.enterScope()
-
-			_, _ = fmt.Fprintf(w, "\n%s:\n", ifBody)
-			p.compileStmt(w, stmt.Body)
-			_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifEnd)
-		}()
-
-		// if.else
-		func() {
-			defer p.restoreScope(p.scope)
-			p.enterScope()
-
-			_, _ = fmt.Fprintf(w, "\n%s:\n", ifElse)
-			if stmt.Else != nil {
-				p.compileStmt(w, stmt.Else)
-				_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifEnd)
-			} else {
-				_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", ifEnd)
-			}
-		}()
-	}()
+		p.b.SetInsertPoint(ifElse)
+		p.compileStmt(stmt.Else)
+		p.br(ifEnd)
+	}
 
 	// end
-	_, _ = fmt.Fprintf(w, "\n%s:\n", ifEnd)
+	p.b.SetInsertPoint(ifEnd)
 }
 
-func (p *Compiler) compileStmtWhile(w io.Writer, stmt *ast.WhileStmt) {
+func (p *Compiler) compileStmtWhile(stmt *ast.WhileStmt) {
 	defer p.restoreScope(p.scope)
 	p.enterScope()
 
+	f := p.b.Func()
 	whilePos := fmt.Sprintf("%d", p.posLine(stmt.While))
-	whileCond := p.genLabelId("while.cond.line" + whilePos)
-	whileBody := p.genLabelId("while.body.line" + whilePos)
-	whileEnd := p.genLabelId("while.end.line" + whilePos)
+	whileCond := f.NewBlock("while.cond.line" + whilePos)
+	whileBody := f.NewBlock("while.body.line" + whilePos)
+	whileEnd := f.NewBlock("while.end.line" + whilePos)
+
+	p.br(whileCond)
 
+	// while.cond
+	p.b.SetInsertPoint(whileCond)
+	p.b.NewCondBr(p.compileCond(stmt.Cond), whileBody, whileEnd)
+
+	// while.body, 结束后回到 while.cond
 	func() {
 		defer p.restoreScope(p.scope)
 		p.enterScope()
 
-		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", whileCond)
-
-		// while.cond
-		_, _ = fmt.Fprintf(w, "\n%s:\n", whileCond)
-		condValue := p.compileExpr(w, stmt.Cond)
-		_, _ = fmt.Fprintf(w, "\tbr i1 %s , label %%%s, label %%%s\n", condValue, whileBody, whileEnd)
-
-		// while.body
-		func() {
-			defer p.restoreScope(p.scope)
-			p.enterScope()
-
-			_, _ = fmt.Fprintf(w, "\n%s:\n", whileBody)
-			p.compileStmt(w, stmt.Body)
-		}()
+		p.b.SetInsertPoint(whileBody)
+		p.compileStmt(stmt.Body)
+		p.br(whileCond)
 	}()
 
 	// end
-	_, _ = fmt.Fprintf(w, "\n%s:\n", whileEnd)
+	p.b.SetInsertPoint(whileEnd)
 }
 
-func (p *Compiler) compileStmtRepeat(w io.Writer, stmt *ast.RepeatStmt) {
+func (p *Compiler) compileStmtRepeat(stmt *ast.RepeatStmt) {
 	defer p.restoreScope(p.scope)
 	p.enterScope()
 
+	f := p.b.Func()
 	repeatPos := fmt.Sprintf("%d", p.posLine(stmt.Repeat))
-	repeatCond := p.genLabelId("repeat.cond.line" + repeatPos)
-	repeatBody := p.genLabelId("repeat.body.line" + repeatPos)
-	repeatEnd := p.genLabelId("repeat.end.line" + repeatPos)
+	repeatBody := f.NewBlock("repeat.body.line" + repeatPos)
+	repeatCond := f.NewBlock("repeat.cond.line" + repeatPos)
+	repeatEnd := f.NewBlock("repeat.end.line" + repeatPos)
+
+	p.br(repeatBody)
 
+	// repeat.body
 	func() {
 		defer p.restoreScope(p.scope)
 		p.enterScope()
 
-		_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", repeatBody)
-
-		// repeat.body
-		func() {
-			defer p.restoreScope(p.scope)
-			p.enterScope()
-
-			_, _ = fmt.Fprintf(w, "\n%s:\n", repeatBody)
-			p.compileStmt(w, stmt.Body)
-			_, _ = fmt.Fprintf(w, "\tbr label %%%s\n", repeatCond)
-		}()
-
-		// repeat.cond
-		_, _ = fmt.Fprintf(w, "\n%s:\n", repeatCond)
-		condValue := p.compileExpr(w, stmt.Cond)
-		_, _ = fmt.Fprintf(w, "\tbr i1 %s , label %%%s, label %%%s\n", condValue, repeatEnd, repeatBody)
+		p.b.SetInsertPoint(repeatBody)
+		p.compileStmt(stmt.Body)
+		p.br(repeatCond)
 	}()
 
+	// repeat.cond
+	p.b.SetInsertPoint(repeatCond)
+	p.b.NewCondBr(p.compileCond(stmt.Cond), repeatEnd, repeatBody)
+
 	// end
-	_, _ = fmt.Fprintf(w, "\n%s:\n", repeatEnd)
+	p.b.SetInsertPoint(repeatEnd)
 }
 
-func (p *Compiler) compileStmtCall(w io.Writer, expr *ast.CallStmt) {
-	var fnName string
-	if _, obj := p.scope.Lookup(expr.ProcedureName.Name); obj != nil {
-		fnName = obj.MangledName
-	} else {
+func (p *Compiler) compileStmtCall(expr *ast.CallStmt) {
+	_, fnObj := p.scope.Lookup(expr.ProcedureName.Name)
+	if fnObj == nil {
 		panic(fmt.Sprintf("proc %s undefined", expr.ProcedureName.Name))
 	}
 
-	var localNames []string
+	var args []ir.Value
 	for _, arg := range expr.Args {
-		localNames = append(localNames, p.compileExpr(w, arg))
-	}
-	_, _ = fmt.Fprintf(w, "\tcall i32 %s(", fnName)
-	first := true
-	for _, localName := range localNames {
-		if first {
-			_, _ = fmt.Fprintf(w, "i32 noundef %s", localName)
-			first = false
-			continue
+		args = append(args, p.compileValue(arg))
+	}
+
+	// 内置的过程直接调用运行时库, 没有静态链
+	if fn, ok := p.builtins[fnObj.Name]; ok && fnObj.Node == nil {
+		for i, arg := range args {
+			if i < len(fn.Sig.Params) && !ir.Equal(arg.Type(), fn.Sig.Params[i]) {
+				args[i] = p.b.NewTrunc(arg, fn.Sig.Params[i])
+			}
 		}
-		_, _ = fmt.Fprintf(w, ", i32 noundef %s", localName)
+		p.b.NewCall(fn, args...)
+		return
 	}
-	_, _ = fmt.Fprintf(w, ")\n")
+
+	// 静态链指向被调用过程的外层过程的活动记录
+	var link ir.Value = ir.NewNull(ir.NewPointer(ir.I8))
+	if parent := p.parents[fnObj]; parent != nil {
+		link = p.b.NewBitCast(p.framePtr(parent), ir.NewPointer(ir.I8))
+	}
+	p.b.NewCall(p.funcs[fnObj], append([]ir.Value{link}, args...)...)
 }
 
-func (p *Compiler) compileIOStmt(w io.Writer, stmt *ast.IOStmt) {
-	var targetName string
+func (p *Compiler) compileIOStmt(stmt *ast.IOStmt) {
 	switch stmt.Type {
 	case token.READ:
 		for _, param := range stmt.Params.List {
-			localName := p.compileExpr(w, param.Name)
-			_, _ = fmt.Fprintf(w, "\tcall i32 @pl_0_builtin_println(i32 %s)\n",
-				localName)
+			p.b.NewCall(p.builtins["println"], p.compileValue(param.Name))
 		}
 	case token.WRITE:
-		if _, obj := p.scope.Lookup(stmt.Params.List[0].Name.Name); obj != nil {
-			targetName = obj.MangledName
-		} else {
+		_, obj := p.scope.Lookup(stmt.Params.List[0].Name.Name)
+		if obj == nil {
 			panic(fmt.Sprintf("var %s undefined", stmt.Params.List[0].Name.Name))
 		}
-		localName := p.genId()
-		_, _ = fmt.Fprintf(w, "\t%s = call i32 @pl_0_builtin_write()\n", localName)
-		_, _ = fmt.Fprintf(w, "\tstore i32 %s, i32* %s, align 4\n",
-			localName, targetName)
+		target := p.varPtr(obj)
+		p.b.NewStore(p.b.NewCall(p.builtins["write"]), target, p.align())
 	}
 }
 
-func (p *Compiler) compileExpr(w io.Writer, expr ast.Expr) (localName string) {
+func (p *Compiler) compileExpr(expr ast.Expr) ir.Value {
 	switch expr := expr.(type) {
 	case *ast.Ident:
-		var varName string
-		if _, obj := p.scope.Lookup(expr.Name); obj != nil {
-			varName = obj.MangledName
-		} else {
+		_, obj := p.scope.Lookup(expr.Name)
+		if obj == nil {
 			panic(fmt.Sprintf("var %s undefined", expr.Name))
 		}
-
-		localName = p.genId()
-		_, _ = fmt.Fprintf(w, "\t%s = load i32, i32* %s, align 4\n",
-			localName, varName,
-		)
-		return localName
+		return p.b.NewLoad(p.varPtr(obj), p.align())
 	case *ast.Number:
-		localName = p.genId()
-		_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-			localName, "add", `0`, expr.Value,
-		)
-		return localName
+		return p.int(int64(expr.Value))
 	case *ast.BinaryExpr:
-		localName = p.genId()
 		switch expr.Op {
-		case token.ADD:
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "add", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
-		case token.SUB:
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "sub", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
-		case token.MUL:
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "mul", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
+		case token.ADD, token.SUB, token.MUL:
+			x := p.compileValue(expr.X)
+			return p.compileArith(expr.Op, x, p.compileValue(expr.Y), expr.OpPos)
 		case token.DIV:
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "div", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
+			x := p.compileValue(expr.X)
+			return p.b.NewSDiv(x, p.compileDivisor(x, expr))
+		case token.MOD:
+			x := p.compileValue(expr.X)
+			return p.b.NewSRem(x, p.compileDivisor(x, expr))
 
 		case token.EQL: // =
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "icmp eq", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
+			x, y := p.compileOperands(expr)
+			return p.b.NewICmp("eq", x, y)
 		case token.NEQ: // <>
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "icmp ne", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
+			x, y := p.compileOperands(expr)
+			return p.b.NewICmp("ne", x, y)
 		case token.LSS: // <
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "icmp slt", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
+			return p.b.NewICmp("slt", p.compileValue(expr.X), p.compileValue(expr.Y))
 		case token.LEQ: // <=
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "icmp sle", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
+			return p.b.NewICmp("sle", p.compileValue(expr.X), p.compileValue(expr.Y))
 		case token.GTR: // >
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "icmp sgt", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
+			return p.b.NewICmp("sgt", p.compileValue(expr.X), p.compileValue(expr.Y))
 		case token.GEQ: // >=
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "icmp sge", p.compileExpr(w, expr.X), p.compileExpr(w, expr.Y),
-			)
-			return localName
+			return p.b.NewICmp("sge", p.compileValue(expr.X), p.compileValue(expr.Y))
 		default:
 			panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
 		}
 	case *ast.UnaryExpr:
-		if expr.Op == token.SUB {
-			localName = p.genId()
-			_, _ = fmt.Fprintf(w, "\t%s = %s i32 %v, %v\n",
-				localName, "sub", `0`, p.compileExpr(w, expr.X),
-			)
-			return localName
+		switch expr.Op {
+		case token.SUB:
+			return p.compileArith(token.SUB, p.int(0), p.compileValue(expr.X), expr.OpPos)
+		case token.ODD:
+			// odd x: 最低位为 1, 对负数同样成立
+			lowBit := p.b.NewAnd(p.compileValue(expr.X), p.int(1))
+			return p.b.NewICmp("ne", lowBit, p.int(0))
 		}
-		return p.compileExpr(w, expr.X)
+		return p.compileExpr(expr.X)
 	case *ast.ParenExpr:
-		return p.compileExpr(w, expr.X)
+		return p.compileExpr(expr.X)
 
 	default:
 		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
 	}
 }
 
-func (p *Compiler) posLine(pos token.Pos) int {
-	if p.program != nil && p.program.Source != "" {
-		line := pos.Position(p.program.FileName, p.program.Source).Line
-		return line
+// compileArith 生成 +, - 和 *. 开启 CheckOverflow 时使用带溢出检查的内建函数,
+// 溢出则调用运行时库报告运算符所在的文件和行并退出.
+func (p *Compiler) compileArith(op token.TokenType, x, y ir.Value, pos token.Pos) ir.Value {
+	if !p.overflow {
+		switch op {
+		case token.ADD:
+			return p.b.NewAdd(x, y)
+		case token.SUB:
+			return p.b.NewSub(x, y)
+		default:
+			return p.b.NewMul(x, y)
+		}
 	}
-	return 0
+
+	var r ir.Value
+	switch op {
+	case token.ADD:
+		r = p.b.NewCall(p.builtins["sadd"], x, y)
+	case token.SUB:
+		r = p.b.NewCall(p.builtins["ssub"], x, y)
+	default:
+		r = p.b.NewCall(p.builtins["smul"], x, y)
+	}
+
+	p.trapOverflow(p.b.NewExtractValue(r, 1), pos)
+	return p.b.NewExtractValue(r, 0)
+}
+
+// trapOverflow 在 cond 为真时调用运行时库报告 pos 所在的文件和行并退出
+func (p *Compiler) trapOverflow(cond ir.Value, pos token.Pos) {
+	f := p.b.Func()
+	ovfPos := fmt.Sprintf("%d", p.posLine(pos))
+	ovfTrap := f.NewBlock("overflow.line" + ovfPos)
+	ovfOk := f.NewBlock("overflow.ok.line" + ovfPos)
+	p.b.NewCondBr(cond, ovfTrap, ovfOk)
+
+	// overflow, 运行时库不会返回
+	p.b.SetInsertPoint(ovfTrap)
+	file := p.fileName(pos)
+	p.b.NewCall(p.builtins["overflow"],
+		p.b.NewGEP(file.Content, file, p.i32(0), p.i32(0)), p.i32(int64(p.posLine(pos))))
+	p.b.NewUnreachable()
+
+	p.b.SetInsertPoint(ovfOk)
+}
+
+// fileName 返回 pos 所在文件名的字符串常量, 每个文件只生成一次
+func (p *Compiler) fileName(pos token.Pos) *ir.Global {
+	name := ""
+	if p.program != nil && p.program.FileSet != nil {
+		name = p.program.FileSet.Position(pos).Filename
+	}
+	if g, ok := p.files[name]; ok {
+		return g
+	}
+	g := p.m.NewConstant(fmt.Sprintf("pl_0_file.%d", len(p.files)), ir.NewCString(name))
+	p.files[name] = g
+	return g
 }
 
-func (p *Compiler) genId() string {
-	id := fmt.Sprintf("%%t%d", p.nextId)
-	p.nextId++
-	return id
+// compileDivisor 生成 x / y 和 x % y 的除数. 开启 CheckDiv 时先检查除数,
+// 为零则调用运行时库报告运算符所在的行并退出. 最小的整数除以 -1 的商溢出,
+// sdiv 和 srem 都会由硬件异常终止, 同样报告为整数溢出.
+func (p *Compiler) compileDivisor(x ir.Value, expr *ast.BinaryExpr) ir.Value {
+	y := p.compileValue(expr.Y)
+	if !p.checkDiv {
+		return y
+	}
+
+	f := p.b.Func()
+	divPos := fmt.Sprintf("%d", p.posLine(expr.OpPos))
+	divZero := f.NewBlock("div.zero.line" + divPos)
+	divOk := f.NewBlock("div.ok.line" + divPos)
+	p.b.NewCondBr(p.b.NewICmp("eq", y, p.int(0)), divZero, divOk)
+
+	// div.zero, 运行时库不会返回
+	p.b.SetInsertPoint(divZero)
+	p.b.NewCall(p.builtins["divzero"], p.i32(int64(p.posLine(expr.OpPos))))
+	p.b.NewUnreachable()
+
+	p.b.SetInsertPoint(divOk)
+	minInt := p.int(-1 << (p.intType.Bits - 1))
+	p.trapOverflow(p.b.NewAnd(p.b.NewICmp("eq", x, minInt), p.b.NewICmp("eq", y, p.int(-1))), expr.OpPos)
+	return y
+}
+
+// compileValue 生成整数值, 条件按 zext 转为 0/1
+func (p *Compiler) compileValue(expr ast.Expr) ir.Value {
+	return p.convert(p.compileExpr(expr), p.typeOf(expr), Int)
+}
+
+// compileCond 生成条件值, 整数按 icmp ne 0 转为条件
+func (p *Compiler) compileCond(expr ast.Expr) ir.Value {
+	return p.convert(p.compileExpr(expr), p.typeOf(expr), Bool)
+}
+
+// compileOperands 生成 = 和 <> 的两个操作数. 两边都是条件时直接比较 i1,
+// 否则都转为整数比较.
+func (p *Compiler) compileOperands(expr *ast.BinaryExpr) (x, y ir.Value) {
+	if p.typeOf(expr.X) == Bool && p.typeOf(expr.Y) == Bool {
+		return p.compileExpr(expr.X), p.compileExpr(expr.Y)
+	}
+	return p.compileValue(expr.X), p.compileValue(expr.Y)
+}
+
+func (p *Compiler) convert(value ir.Value, from, to Type) ir.Value {
+	if from == to {
+		return value
+	}
+	switch to {
+	case Int:
+		return p.b.NewZExt(value, p.intType)
+	default:
+		return p.b.NewICmp("ne", value, p.int(0))
+	}
+}
+
+// typeOf 返回表达式的类型, 没有检查结果时按语法推断
+func (p *Compiler) typeOf(expr ast.Expr) Type {
+	if t, ok := p.types[expr]; ok && t != Invalid {
+		return t
+	}
+	return TypeOf(expr)
 }
 
-func (p *Compiler) genLabelId(name string) string {
-	id := fmt.Sprintf("%s.%d", name, p.nextId)
-	p.nextId++
-	return id
+func (p *Compiler) posLine(pos token.Pos) int {
+	if p.program != nil && p.program.FileSet != nil {
+		return p.program.FileSet.Position(pos).Line
+	}
+	return 0
 }
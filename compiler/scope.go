@@ -7,10 +7,34 @@ type Scope struct {
 	Objects map[string]*Object
 }
 
+// ObjKind 对象的种类
+type ObjKind int
+
+const (
+	Bad   ObjKind = iota // 未知
+	Con                  // 常量
+	Var                  // 变量
+	Param                // 过程参数
+	Proc                 // 过程
+)
+
+var objKindStrings = [...]string{
+	Bad:   "bad",
+	Con:   "const",
+	Var:   "var",
+	Param: "param",
+	Proc:  "procedure",
+}
+
+func (kind ObjKind) String() string { return objKindStrings[kind] }
+
 type Object struct {
 	Name        string
 	MangledName string
+	Kind        ObjKind
 	Type        string
+	Value       int64 // 常量的值
+	NumParams   int   // 内置过程的参数个数, 声明的过程以 ast.ProcDecl 为准
 	ast.Node
 }
 
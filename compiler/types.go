@@ -0,0 +1,41 @@
+package compiler
+
+import (
+	"pl0Compiler/ast"
+	"pl0Compiler/token"
+)
+
+// Type 表达式的类型
+type Type int
+
+const (
+	Invalid Type = iota // 类型错误
+	Int                 // 整数, 位数由 Option.IntWidth 决定
+	Bool                // 条件, 对应 i1
+)
+
+var typeStrings = [...]string{
+	Invalid: "invalid",
+	Int:     "integer",
+	Bool:    "condition",
+}
+
+func (t Type) String() string { return typeStrings[t] }
+
+// TypeOf 按语法推断表达式的类型: 比较和 odd 为条件, 其余为整数
+func TypeOf(expr ast.Expr) Type {
+	switch expr := expr.(type) {
+	case *ast.BinaryExpr:
+		switch expr.Op {
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return Bool
+		}
+	case *ast.UnaryExpr:
+		if expr.Op == token.ODD {
+			return Bool
+		}
+	case *ast.ParenExpr:
+		return TypeOf(expr.X)
+	}
+	return Int
+}
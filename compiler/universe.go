@@ -3,8 +3,8 @@ package compiler
 var Universe *Scope = NewScope(nil)
 
 var builtinObjects = []*Object{
-	{Name: "println", MangledName: "@tiny_go_builtin_println"},
-	{Name: "exit", MangledName: "@tiny_go_builtin_exit"},
+	{Name: "println", Kind: Proc, NumParams: 1},
+	{Name: "exit", Kind: Proc, NumParams: 1},
 }
 
 func init() {
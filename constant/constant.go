@@ -0,0 +1,116 @@
+// Package constant 对常量表达式求值.
+package constant
+
+import (
+	"fmt"
+	"math/big"
+	"pl0Compiler/ast"
+	"pl0Compiler/token"
+)
+
+// Error 常量求值的错误, Pos 为出错的表达式位置
+type Error struct {
+	Pos token.Pos
+	Msg string
+}
+
+func (e *Error) Error() string { return e.Msg }
+
+// Lookup 返回已声明常量的值, name 不是常量时 ok 为 false
+type Lookup func(name string) (value int64, ok bool)
+
+// Eval 折叠常量表达式: 数字, 之前声明的常量, 一元负号和四则运算.
+// 数字和每一步运算的结果超出 i32 的范围时报告溢出. 错误的类型为 *Error.
+func Eval(expr ast.Expr, lookup Lookup) (value int64, err error) {
+	return EvalWidth(expr, 32, lookup)
+}
+
+// EvalWidth 与 Eval 相同, 但整数为 bits 位 (32 或 64)
+func EvalWidth(expr ast.Expr, bits int, lookup Lookup) (value int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*Error)
+			if !ok {
+				panic(r)
+			}
+			value, err = 0, e
+		}
+	}()
+	e := &evaluator{bits: bits, lookup: lookup}
+	return e.eval(expr), nil
+}
+
+// Wrap 把 x 截断为 bits 位的有符号整数, 与生成代码的溢出行为一致
+func Wrap(x int64, bits int) int64 {
+	if bits == 64 {
+		return x
+	}
+	return int64(int32(x))
+}
+
+// Fits 报告 x 是否在 bits 位有符号整数的范围内
+func Fits(x int64, bits int) bool {
+	return Wrap(x, bits) == x
+}
+
+func errorf(pos token.Pos, format string, args ...interface{}) {
+	panic(&Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+type evaluator struct {
+	bits   int
+	lookup Lookup
+}
+
+func (e *evaluator) eval(expr ast.Expr) int64 {
+	switch expr := expr.(type) {
+	case *ast.Number:
+		return e.fit(expr.ValuePos, big.NewInt(int64(expr.Value)))
+	case *ast.Ident:
+		value, ok := e.lookup(expr.Name)
+		if !ok {
+			errorf(expr.NamePos, "%s is not a constant", expr.Name)
+		}
+		return value
+	case *ast.ParenExpr:
+		return e.eval(expr.X)
+	case *ast.UnaryExpr:
+		if expr.Op != token.SUB {
+			errorf(expr.OpPos, "invalid constant operator %s", expr.Op)
+		}
+		return e.fit(expr.OpPos, new(big.Int).Neg(big.NewInt(e.eval(expr.X))))
+	case *ast.BinaryExpr:
+		x := big.NewInt(e.eval(expr.X))
+		y := big.NewInt(e.eval(expr.Y))
+		z := new(big.Int)
+		switch expr.Op {
+		case token.ADD:
+			return e.fit(expr.OpPos, z.Add(x, y))
+		case token.SUB:
+			return e.fit(expr.OpPos, z.Sub(x, y))
+		case token.MUL:
+			return e.fit(expr.OpPos, z.Mul(x, y))
+		case token.DIV:
+			if y.Sign() == 0 {
+				errorf(expr.OpPos, "division by zero in constant expression")
+			}
+			// Quo 和 Rem 向零取整, 与 sdiv 和 srem 一致
+			return e.fit(expr.OpPos, z.Quo(x, y))
+		case token.MOD:
+			if y.Sign() == 0 {
+				errorf(expr.OpPos, "division by zero in constant expression")
+			}
+			return e.fit(expr.OpPos, z.Rem(x, y))
+		}
+		errorf(expr.OpPos, "invalid constant operator %s", expr.Op)
+	}
+	panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
+}
+
+// fit 返回 x 的值, x 超出 bits 位有符号整数的范围时在 pos 处报告溢出
+func (e *evaluator) fit(pos token.Pos, x *big.Int) int64 {
+	if !x.IsInt64() || !Fits(x.Int64(), e.bits) {
+		errorf(pos, "constant %s overflows %d-bit integer", x, e.bits)
+	}
+	return x.Int64()
+}
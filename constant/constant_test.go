@@ -0,0 +1,118 @@
+package constant_test
+
+import (
+	"pl0Compiler/ast"
+	"pl0Compiler/constant"
+	"pl0Compiler/parser"
+	"pl0Compiler/token"
+	"testing"
+)
+
+// parse 返回 "const c = src;" 中的常量表达式和文件集
+func parse(t *testing.T, src string) (ast.Expr, *token.FileSet) {
+	t.Helper()
+	fset := token.NewFileSet()
+	program, err := parser.ParseFile(fset, "test.pl", "const c = "+src+";\nbegin\nend.")
+	if err != nil {
+		t.Fatalf("%s: %v", src, err)
+	}
+	return program.Const[0].Definition[0].Value, fset
+}
+
+// lookup 提供测试中已声明的常量
+func lookup(name string) (int64, bool) {
+	switch name {
+	case "ten":
+		return 10, true
+	case "max":
+		return 2147483647, true
+	}
+	return 0, false
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src  string
+		bits int
+		want int64
+	}{
+		{"1 + 2 * 3", 32, 7},
+		{"(1 + 2) * 3", 32, 9},
+		{"-ten * 4", 32, -40},
+		{"ten - 3 - 2", 32, 5},
+		{"7 / 2", 32, 3},
+		{"-7 / 2", 32, -3},
+		{"-7 % 2", 32, -1},
+		{"7 % -2", 32, 1},
+		{"max", 32, 2147483647},
+		{"-max - 1", 32, -2147483648},
+		{"max + 1", 64, 2147483648},
+		{"max * max", 64, 4611686014132420609},
+		{"3000000000", 64, 3000000000},
+	}
+	for _, tt := range tests {
+		expr, _ := parse(t, tt.src)
+		got, err := constant.EvalWidth(expr, tt.bits, lookup)
+		if err != nil {
+			t.Errorf("%s (i%d): %v", tt.src, tt.bits, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s (i%d) = %d, want %d", tt.src, tt.bits, got, tt.want)
+		}
+	}
+}
+
+func TestEvalError(t *testing.T) {
+	tests := []struct {
+		src  string
+		bits int
+		col  int // 出错的位置在 "const c = " 之后的列
+		msg  string
+	}{
+		{"1 / 0", 32, 3, "division by zero in constant expression"},
+		{"ten % (ten - 10)", 32, 5, "division by zero in constant expression"},
+		{"3000000000", 32, 1, "constant 3000000000 overflows 32-bit integer"},
+		{"max + 1", 32, 5, "constant 2147483648 overflows 32-bit integer"},
+		{"-max - 2", 32, 6, "constant -2147483649 overflows 32-bit integer"},
+		{"ten * max", 32, 5, "constant 21474836470 overflows 32-bit integer"},
+		{"-(-max - 1)", 32, 1, "constant 2147483648 overflows 32-bit integer"},
+		{"(-max - 1) / -1", 32, 12, "constant 2147483648 overflows 32-bit integer"},
+		{"9223372036854775807 + 1", 64, 21, "constant 9223372036854775808 overflows 64-bit integer"},
+		{"undefined + 1", 32, 1, "undefined is not a constant"},
+	}
+	for _, tt := range tests {
+		expr, fset := parse(t, tt.src)
+		_, err := constant.EvalWidth(expr, tt.bits, lookup)
+		e, ok := err.(*constant.Error)
+		if !ok {
+			t.Errorf("%s (i%d): err = %v, want %s", tt.src, tt.bits, err, tt.msg)
+			continue
+		}
+		if e.Msg != tt.msg {
+			t.Errorf("%s (i%d): err = %s, want %s", tt.src, tt.bits, e.Msg, tt.msg)
+		}
+		if pos := fset.Position(e.Pos); pos.Line != 1 || pos.Column != len("const c = ")+tt.col {
+			t.Errorf("%s (i%d): error at %s, want column %d", tt.src, tt.bits, pos, len("const c = ")+tt.col)
+		}
+	}
+}
+
+func TestFits(t *testing.T) {
+	tests := []struct {
+		x    int64
+		bits int
+		want bool
+	}{
+		{2147483647, 32, true},
+		{-2147483648, 32, true},
+		{2147483648, 32, false},
+		{-2147483649, 32, false},
+		{2147483648, 64, true},
+	}
+	for _, tt := range tests {
+		if got := constant.Fits(tt.x, tt.bits); got != tt.want {
+			t.Errorf("Fits(%d, %d) = %v, want %v", tt.x, tt.bits, got, tt.want)
+		}
+	}
+}
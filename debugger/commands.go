@@ -0,0 +1,282 @@
+package debugger
+
+import (
+	"fmt"
+	"pl0Compiler/interp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// command 调试命令. run 返回是否恢复程序的执行, ok 为 false 时终止程序.
+type command struct {
+	names []string
+	args  string
+	help  string
+	run   func(d *Debugger, args []string) (resume, ok bool)
+}
+
+var commands []*command
+
+func init() {
+	// help 命令引用 commands, 在 init 中初始化以避免初始化循环
+	commands = []*command{
+		{[]string{"break", "b"}, "[file:]line", "set a breakpoint at the first statement on a line", (*Debugger).cmdBreak},
+		{[]string{"delete", "d"}, "[id]", "delete a breakpoint, or all breakpoints", (*Debugger).cmdDelete},
+		{[]string{"breakpoints"}, "", "list breakpoints", (*Debugger).cmdBreakpoints},
+		{[]string{"continue", "c", "run", "r"}, "", "run until the next breakpoint", (*Debugger).cmdContinue},
+		{[]string{"step", "s"}, "", "run to the next statement, entering called procedures", (*Debugger).cmdStep},
+		{[]string{"next", "n"}, "", "run to the next statement, stepping over calls", (*Debugger).cmdNext},
+		{[]string{"finish", "fin"}, "", "run until the current procedure returns", (*Debugger).cmdFinish},
+		{[]string{"print", "p"}, "[name...]", "print variables visible in the selected frame", (*Debugger).cmdPrint},
+		{[]string{"backtrace", "bt"}, "", "show the call stack", (*Debugger).cmdBacktrace},
+		{[]string{"frame", "f"}, "[n]", "select a frame of the call stack", (*Debugger).cmdFrame},
+		{[]string{"list", "l"}, "[[file:]line]", "show source code around a line", (*Debugger).cmdList},
+		{[]string{"help", "h"}, "", "show this help", (*Debugger).cmdHelp},
+		{[]string{"quit", "q"}, "", "stop the program and exit", (*Debugger).cmdQuit},
+	}
+}
+
+func lookup(name string) *command {
+	for _, cmd := range commands {
+		for _, x := range cmd.names {
+			if x == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// running 报告程序是否停在某条语句上, 没有时输出提示
+func (d *Debugger) running() bool {
+	if d.frames == nil {
+		fmt.Fprintln(d.out, "the program is not running")
+		return false
+	}
+	return true
+}
+
+// location 解析 [file:]line, 省略文件时为当前语句所在的文件或主程序所在的文件
+func (d *Debugger) location(arg string) (file string, line int, err error) {
+	file = d.program.FileName
+	if d.frames != nil {
+		f := d.frames[len(d.frames)-1-d.selected]
+		file = d.fset.Position(f.Stmt.Pos()).Filename
+	}
+	if i := strings.LastIndex(arg, ":"); i >= 0 {
+		name := arg[:i]
+		var ok bool
+		if file, ok = d.findFile(name); !ok {
+			return "", 0, fmt.Errorf("no file %s in the program", name)
+		}
+		arg = arg[i+1:]
+	}
+	line, err = strconv.Atoi(arg)
+	if err != nil || line < 1 {
+		return "", 0, fmt.Errorf("invalid line number %q", arg)
+	}
+	return file, line, nil
+}
+
+func (d *Debugger) cmdBreak(args []string) (bool, bool) {
+	if len(args) != 1 {
+		fmt.Fprintln(d.out, "usage: break [file:]line")
+		return false, true
+	}
+	file, line, err := d.location(args[0])
+	if err != nil {
+		fmt.Fprintln(d.out, err)
+		return false, true
+	}
+
+	// 没有语句的行把断点移到之后第一条语句所在的行
+	lines := d.lines[file]
+	i := sort.SearchInts(lines, line)
+	if i == len(lines) {
+		fmt.Fprintf(d.out, "no statement at or after %s:%d\n", file, line)
+		return false, true
+	}
+	b := &Breakpoint{ID: d.nextID, File: file, Line: lines[i]}
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, b)
+	fmt.Fprintf(d.out, "breakpoint %d at %s:%d\n", b.ID, b.File, b.Line)
+	return false, true
+}
+
+func (d *Debugger) cmdDelete(args []string) (bool, bool) {
+	if len(args) == 0 {
+		d.breakpoints = nil
+		return false, true
+	}
+	id, err := strconv.Atoi(args[0])
+	if err == nil {
+		for i, b := range d.breakpoints {
+			if b.ID == id {
+				d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+				return false, true
+			}
+		}
+	}
+	fmt.Fprintf(d.out, "no breakpoint %s\n", args[0])
+	return false, true
+}
+
+func (d *Debugger) cmdBreakpoints(args []string) (bool, bool) {
+	if len(d.breakpoints) == 0 {
+		fmt.Fprintln(d.out, "no breakpoints")
+	}
+	for _, b := range d.breakpoints {
+		fmt.Fprintf(d.out, "%d\t%s:%d\thit %d times\n", b.ID, b.File, b.Line, b.Hits)
+	}
+	return false, true
+}
+
+func (d *Debugger) cmdContinue(args []string) (bool, bool) {
+	d.mode = modeContinue
+	return true, true
+}
+
+func (d *Debugger) cmdStep(args []string) (bool, bool) {
+	d.mode = modeStep
+	return true, true
+}
+
+func (d *Debugger) cmdNext(args []string) (bool, bool) {
+	if d.frames == nil {
+		// 程序还没有开始, 停在第一条语句上
+		d.mode = modeStep
+		return true, true
+	}
+	d.mode, d.depth = modeNext, len(d.frames)
+	return true, true
+}
+
+func (d *Debugger) cmdFinish(args []string) (bool, bool) {
+	if !d.running() {
+		return false, true
+	}
+	if len(d.frames) == 1 {
+		fmt.Fprintln(d.out, `"finish" not meaningful in the outermost frame`)
+		return false, true
+	}
+	d.mode, d.depth = modeFinish, len(d.frames)
+	return true, true
+}
+
+// cmdPrint 显示选中的栈帧中可见的变量, 包括外层过程和全局的变量
+func (d *Debugger) cmdPrint(args []string) (bool, bool) {
+	if !d.running() {
+		return false, true
+	}
+	env := d.frames[len(d.frames)-1-d.selected].Env
+	if len(args) > 0 {
+		for _, name := range args {
+			if _, obj := env.Lookup(name); obj != nil {
+				d.printObject(obj)
+			} else {
+				fmt.Fprintf(d.out, "no symbol %s in the current scope\n", name)
+			}
+		}
+		return false, true
+	}
+
+	// 由内向外显示每一层作用域的变量, 被遮蔽的变量不显示
+	seen := make(map[string]bool)
+	for ; env != nil; env = env.Outer {
+		names := make([]string, 0, len(env.Objects))
+		for name, obj := range env.Objects {
+			if obj.Kind == interp.Var && !seen[name] {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			d.printObject(env.Objects[name])
+		}
+		for name := range env.Objects {
+			seen[name] = true
+		}
+	}
+	return false, true
+}
+
+func (d *Debugger) printObject(obj *interp.Object) {
+	switch obj.Kind {
+	case interp.Var:
+		fmt.Fprintf(d.out, "%s = %d\n", obj.Name, obj.Value)
+	case interp.Const:
+		fmt.Fprintf(d.out, "%s = %d (const)\n", obj.Name, obj.Value)
+	default:
+		fmt.Fprintf(d.out, "%s is a procedure\n", obj.Name)
+	}
+}
+
+func (d *Debugger) cmdBacktrace(args []string) (bool, bool) {
+	if !d.running() {
+		return false, true
+	}
+	for i := range d.frames {
+		f := d.frames[len(d.frames)-1-i]
+		mark := " "
+		if i == d.selected {
+			mark = "*"
+		}
+		fmt.Fprintf(d.out, "%s#%d %s at %s\n", mark, i, frameName(f), d.fset.Position(f.Stmt.Pos()))
+	}
+	return false, true
+}
+
+func (d *Debugger) cmdFrame(args []string) (bool, bool) {
+	if !d.running() {
+		return false, true
+	}
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 || n >= len(d.frames) {
+			fmt.Fprintf(d.out, "no frame %s\n", args[0])
+			return false, true
+		}
+		d.selected = n
+	}
+	fmt.Fprintf(d.out, "#%d ", d.selected)
+	d.where()
+	return false, true
+}
+
+func (d *Debugger) cmdList(args []string) (bool, bool) {
+	var (
+		file string
+		line int
+	)
+	switch {
+	case len(args) > 0:
+		var err error
+		if file, line, err = d.location(args[0]); err != nil {
+			fmt.Fprintln(d.out, err)
+			return false, true
+		}
+	case d.frames != nil:
+		pos := d.fset.Position(d.frames[len(d.frames)-1-d.selected].Stmt.Pos())
+		file, line = pos.Filename, pos.Line
+	default:
+		file, line = d.program.FileName, 1
+	}
+	d.list(file, line-5, line+5)
+	return false, true
+}
+
+func (d *Debugger) cmdHelp(args []string) (bool, bool) {
+	for _, cmd := range commands {
+		usage := strings.Join(cmd.names, ", ")
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Fprintf(d.out, "  %-28s %s\n", usage, cmd.help)
+	}
+	return false, true
+}
+
+func (d *Debugger) cmdQuit(args []string) (bool, bool) {
+	return false, false
+}
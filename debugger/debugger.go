@@ -0,0 +1,301 @@
+// Package debugger 实现 pl/0 程序的源代码级调试器.
+//
+// 调试器在解释器上运行程序, 在执行每条语句前检查断点和单步状态,
+// 停下时从命令输入读取命令. 命令可以来自文件, 以便非交互地运行调试脚本.
+package debugger
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pl0Compiler/ast"
+	"pl0Compiler/interp"
+	"pl0Compiler/token"
+	"sort"
+	"strings"
+)
+
+const prompt = "(pl0db) "
+
+// Option 调试器的输入输出
+type Option struct {
+	Stdin    io.Reader // 程序的输入
+	Stdout   io.Writer // 程序和调试器的输出
+	Commands io.Reader // 调试命令, 为 nil 时从 Stdin 读取
+	Echo     bool      // 回显读到的命令, 从脚本读取命令时使用
+	IntWidth int       // 整数的位数, 32 或 64, 为 0 时是 32
+}
+
+// Breakpoint 行断点
+type Breakpoint struct {
+	ID   int
+	File string
+	Line int
+	Hits int
+}
+
+// mode 程序恢复执行后在哪里停下
+type mode int
+
+const (
+	modeContinue mode = iota // 只在断点处停下
+	modeStep                 // 下一条语句, 包括进入调用的过程
+	modeNext                 // 当前过程或调用者中的下一条语句
+	modeFinish               // 返回到调用者之后的下一条语句
+	modeDetach               // 命令已经读完, 不再停下
+)
+
+// quit 由 quit 命令抛出, 终止程序的执行
+type quit struct{}
+
+// Debugger 源代码级调试器
+type Debugger struct {
+	program *ast.Program
+	fset    *token.FileSet
+	interp  *interp.Interp
+	cmds    *bufio.Reader
+	out     io.Writer
+	echo    bool
+
+	breakpoints []*Breakpoint
+	nextID      int
+	lines       map[string][]int  // 每个文件中可以停下的行, 升序
+	sources     map[string]string // 文件名对应的源代码, 用于显示代码
+
+	mode     mode
+	depth    int             // 开始 next 或 finish 时的调用深度
+	last     *stop           // 上一次执行的语句, 同一行的多条语句只在断点处停一次
+	frames   []*interp.Frame // 停下时的调用栈, 没有运行时为 nil
+	selected int             // 选中的栈帧, 0 为最内层
+}
+
+// stop 执行到的语句的位置
+type stop struct {
+	pos   token.Position
+	depth int
+}
+
+// New 创建调试器, program 应该已经通过类型检查
+func New(program *ast.Program, opt *Option) *Debugger {
+	stdin := opt.Stdin
+	cmds := bufio.NewReader(opt.Commands)
+	if opt.Commands == nil || opt.Commands == opt.Stdin {
+		// 程序和调试命令共用同一个输入, 使用同一个缓冲
+		cmds = bufio.NewReader(opt.Stdin)
+		stdin = cmds
+	}
+
+	d := &Debugger{
+		program: program,
+		fset:    program.FileSet,
+		interp:  interp.NewInterp(program, stdin, opt.Stdout),
+		cmds:    cmds,
+		out:     opt.Stdout,
+		echo:    opt.Echo,
+		lines:   make(map[string][]int),
+		sources: make(map[string]string),
+		nextID:  1,
+	}
+	if opt.IntWidth != 0 {
+		d.interp.SetIntWidth(opt.IntWidth)
+	}
+	d.index(program, make(map[*ast.Program]bool))
+	for file, lines := range d.lines {
+		sort.Ints(lines)
+		d.lines[file] = lines
+	}
+	d.interp.SetHook(d.hook)
+	return d
+}
+
+// index 记录程序及其导入的模块中语句所在的行
+func (d *Debugger) index(program *ast.Program, visited map[*ast.Program]bool) {
+	if visited[program] {
+		return
+	}
+	visited[program] = true
+	if program.FileName != "" {
+		d.sources[program.FileName] = program.Source
+	}
+	for _, imp := range program.Imports {
+		if imp.Module != nil {
+			d.index(imp.Module, visited)
+		}
+	}
+
+	seen := make(map[token.Position]bool)
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.BlockStmt, *ast.VarDecl:
+			return true
+		case ast.Stmt:
+			if !n.Pos().IsValid() {
+				return true
+			}
+			pos := d.fset.Position(n.Pos())
+			key := token.Position{Filename: pos.Filename, Line: pos.Line}
+			if !seen[key] {
+				seen[key] = true
+				d.lines[pos.Filename] = append(d.lines[pos.Filename], pos.Line)
+			}
+		}
+		return true
+	})
+}
+
+// Run 读取命令直到程序开始执行, 然后运行程序直到结束或 quit 命令
+func (d *Debugger) Run() (err error) {
+	if !d.prompt() {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(quit); !ok {
+				panic(r)
+			}
+			err = nil
+		}
+	}()
+
+	err = d.interp.Run()
+	if e, ok := err.(*interp.ExitError); ok {
+		fmt.Fprintf(d.out, "program exited with status %d\n", e.Code)
+		return nil
+	}
+	if err == nil {
+		fmt.Fprintln(d.out, "program exited")
+	}
+	return err
+}
+
+// hook 在每条语句执行前由解释器调用, 判断是否停下
+func (d *Debugger) hook(stmt ast.Stmt, env *interp.Env) {
+	frames := d.interp.Frames()
+	cur := &stop{pos: d.fset.Position(stmt.Pos()), depth: len(frames)}
+	sameLine := d.last != nil && d.last.depth == cur.depth &&
+		d.last.pos.Filename == cur.pos.Filename && d.last.pos.Line == cur.pos.Line
+	d.last = cur
+
+	var hit *Breakpoint
+	if !sameLine {
+		for _, b := range d.breakpoints {
+			if b.File == cur.pos.Filename && b.Line == cur.pos.Line {
+				hit = b
+				break
+			}
+		}
+	}
+
+	switch {
+	case d.mode == modeDetach:
+		return
+	case hit != nil:
+		hit.Hits++
+		fmt.Fprintf(d.out, "breakpoint %d, ", hit.ID)
+	case d.mode == modeStep:
+	case d.mode == modeNext && cur.depth <= d.depth:
+	case d.mode == modeFinish && cur.depth < d.depth:
+	default:
+		return
+	}
+
+	d.frames, d.selected = frames, 0
+	d.where()
+	if !d.prompt() {
+		panic(quit{})
+	}
+	d.frames = nil
+}
+
+// prompt 读取并执行命令, 直到一个恢复执行的命令. quit 时返回 false.
+// 命令输入结束时程序运行到结束, 不再停下.
+func (d *Debugger) prompt() bool {
+	for {
+		fmt.Fprint(d.out, prompt)
+		line, err := d.cmds.ReadString('\n')
+		if d.echo && line != "" {
+			fmt.Fprint(d.out, line)
+			if !strings.HasSuffix(line, "\n") {
+				fmt.Fprintln(d.out)
+			}
+		}
+		if line == "" && err != nil {
+			fmt.Fprintln(d.out)
+			d.mode = modeDetach
+			return true
+		}
+
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		cmd := lookup(args[0])
+		if cmd == nil {
+			fmt.Fprintf(d.out, "unknown command %q, type help for help\n", args[0])
+			continue
+		}
+		resume, ok := cmd.run(d, args[1:])
+		if !ok {
+			return false
+		}
+		if resume {
+			return true
+		}
+	}
+}
+
+// where 显示选中的栈帧正在执行的语句
+func (d *Debugger) where() {
+	f := d.frames[len(d.frames)-1-d.selected]
+	pos := d.fset.Position(f.Stmt.Pos())
+	fmt.Fprintf(d.out, "%s at %s\n", frameName(f), pos)
+	d.list(pos.Filename, pos.Line, pos.Line)
+}
+
+// list 显示文件中 from 到 to 行的源代码
+func (d *Debugger) list(file string, from, to int) {
+	lines := strings.Split(d.source(file), "\n")
+	if from < 1 {
+		from = 1
+	}
+	if to > len(lines) {
+		to = len(lines)
+	}
+	for i := from; i <= to; i++ {
+		fmt.Fprintf(d.out, "%4d\t%s\n", i, strings.TrimRight(lines[i-1], "\r"))
+	}
+}
+
+// source 返回文件的源代码, 合并的多个文件中只有主程序所在的文件保存了源代码
+func (d *Debugger) source(file string) string {
+	src, ok := d.sources[file]
+	if !ok {
+		data, _ := os.ReadFile(file)
+		src = string(data)
+		d.sources[file] = src
+	}
+	return src
+}
+
+// findFile 按文件名查找程序中的文件, 可以省略目录
+func (d *Debugger) findFile(name string) (string, bool) {
+	if _, ok := d.lines[name]; ok {
+		return name, true
+	}
+	for file := range d.lines {
+		if filepath.Base(file) == name || strings.HasSuffix(filepath.ToSlash(file), "/"+filepath.ToSlash(name)) {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// frameName 返回栈帧所在的过程名, 主程序为 main
+func frameName(f *interp.Frame) string {
+	if f.Proc == nil {
+		return "main"
+	}
+	return f.Proc.Name
+}
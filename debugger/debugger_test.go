@@ -0,0 +1,191 @@
+package debugger_test
+
+import (
+	"bytes"
+	"pl0Compiler/build"
+	"pl0Compiler/debugger"
+	"strings"
+	"testing"
+)
+
+const testSrc = `var x;
+procedure outer(a);
+    var y;
+    procedure inner;
+    begin
+        y := a + x;
+        read y;
+    end;
+begin
+    y := 0;
+    call inner;
+    x := y;
+end;
+begin
+    x := 1;
+    call outer(2);
+    read x;
+end.
+`
+
+// debug 用调试器运行 testSrc, 执行 script 中的命令, 返回回显命令的记录
+func debug(t *testing.T, script string) string {
+	t.Helper()
+	program, err := build.NewContext(nil).Check([]string{"test.pl"}, []interface{}{testSrc}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	opt := &debugger.Option{
+		Stdin:    strings.NewReader(""),
+		Stdout:   &out,
+		Commands: bytes.NewBufferString(script),
+		Echo:     true,
+	}
+	if err := debugger.New(program, opt).Run(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestDebugger(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{
+			// 断点停在内层过程中, print 显示外层过程和全局的变量
+			"break",
+			"break 6\nrun\nprint\nbacktrace\nfinish\nnext\nnext\n",
+			`(pl0db) break 6
+breakpoint 1 at test.pl:6
+(pl0db) run
+breakpoint 1, inner at test.pl:6:9
+   6	        y := a + x;
+(pl0db) print
+a = 2
+y = 0
+x = 1
+(pl0db) backtrace
+*#0 inner at test.pl:6:9
+ #1 outer at test.pl:11:5
+ #2 main at test.pl:16:5
+(pl0db) finish
+3
+outer at test.pl:12:5
+  12	    x := y;
+(pl0db) next
+main at test.pl:17:5
+  17	    read x;
+(pl0db) next
+3
+program exited
+`,
+		},
+		{
+			// step 进入调用的过程, next 跳过 read 回到调用者
+			"step",
+			"break 16\nrun\nstep\nstep\nstep\nnext\nnext\nstep\nquit\n",
+			`(pl0db) break 16
+breakpoint 1 at test.pl:16
+(pl0db) run
+breakpoint 1, main at test.pl:16:5
+  16	    call outer(2);
+(pl0db) step
+outer at test.pl:10:5
+  10	    y := 0;
+(pl0db) step
+outer at test.pl:11:5
+  11	    call inner;
+(pl0db) step
+inner at test.pl:6:9
+   6	        y := a + x;
+(pl0db) next
+inner at test.pl:7:9
+   7	        read y;
+(pl0db) next
+3
+outer at test.pl:12:5
+  12	    x := y;
+(pl0db) step
+main at test.pl:17:5
+  17	    read x;
+(pl0db) quit
+`,
+		},
+		{
+			// 程序开始前 next 停在第一条语句, 跳过调用时仍然在断点处停下
+			"next",
+			"next\nnext\nbreak 7\nbreakpoints\nnext\ncontinue\n",
+			`(pl0db) next
+main at test.pl:15:5
+  15	    x := 1;
+(pl0db) next
+main at test.pl:16:5
+  16	    call outer(2);
+(pl0db) break 7
+breakpoint 1 at test.pl:7
+(pl0db) breakpoints
+1	test.pl:7	hit 0 times
+(pl0db) next
+breakpoint 1, inner at test.pl:7:9
+   7	        read y;
+(pl0db) continue
+3
+3
+program exited
+`,
+		},
+		{
+			// frame 选择调用者的栈帧, print 显示该栈帧中可见的变量
+			"frame",
+			"break 7\ncontinue\nframe 1\nprint\nprint y a nope\nframe 5\nbacktrace\nfinish\nfinish\nfinish\nbreakpoints\ndelete 1\nbreakpoints\ncontinue\n",
+			`(pl0db) break 7
+breakpoint 1 at test.pl:7
+(pl0db) continue
+breakpoint 1, inner at test.pl:7:9
+   7	        read y;
+(pl0db) frame 1
+#1 outer at test.pl:11:5
+  11	    call inner;
+(pl0db) print
+a = 2
+y = 3
+x = 1
+(pl0db) print y a nope
+y = 3
+a = 2
+no symbol nope in the current scope
+(pl0db) frame 5
+no frame 5
+(pl0db) backtrace
+ #0 inner at test.pl:7:9
+*#1 outer at test.pl:11:5
+ #2 main at test.pl:16:5
+(pl0db) finish
+3
+outer at test.pl:12:5
+  12	    x := y;
+(pl0db) finish
+main at test.pl:17:5
+  17	    read x;
+(pl0db) finish
+"finish" not meaningful in the outermost frame
+(pl0db) breakpoints
+1	test.pl:7	hit 1 times
+(pl0db) delete 1
+(pl0db) breakpoints
+no breakpoints
+(pl0db) continue
+3
+program exited
+`,
+		},
+	}
+	for _, tt := range tests {
+		if got := debug(t, tt.script); got != tt.want {
+			t.Errorf("%s: transcript =\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+// 三种注释风格
+{ 花括号注释
+  可以跨行 }
+const n = 5; (* 求 1..n 的和 *)
+var i, sum;
+begin
+  i := 1;
+  sum := 0; // 行尾注释
+  while i <= n do
+  begin
+    sum := sum + i; (* 累加 *)
+    i := i + 1;
+  end;
+  read sum;
+end.
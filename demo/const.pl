@@ -0,0 +1,5 @@
+const width = 8, height = width / 2 + 1;
+const area = width * height, neg = -area, half = (area - neg) / 4;
+begin
+  read width, height, area, neg, half;
+end.
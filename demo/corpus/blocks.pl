@@ -0,0 +1,21 @@
+// 块中声明的变量和条件的比较
+var a, b;
+begin
+  a := 0;
+  while a < 3 do
+  begin
+    var t;
+    t := a * a;
+    if (t > 1) = odd a then
+    begin
+      var u;
+      u := t + 1;
+      read u;
+    end
+    else
+      read t;
+    a := a + 1;
+  end;
+  if (a > 2) <> (a < 2) then b := a;
+  read b;
+end.
@@ -0,0 +1,15 @@
+// 空的语句体
+var x;
+procedure nop;;
+procedure empty;
+begin
+end;
+begin
+  x := 3;
+  if x > 0 then ;
+  if x < 0 then ; else ;
+  while x > 0 do x := x - 1;
+  repeat ; until x = 0;
+  call empty;
+  read x;
+end.
@@ -0,0 +1,18 @@
+// if/else 链和没有 else 的 if
+var x, sign;
+begin
+  x := -4;
+  repeat
+  begin
+    if x < 0 then
+      sign := -1;
+    else if x = 0 then
+      sign := 0;
+    else
+      sign := 1;
+    read sign;
+    if odd x then read x;
+    x := x + 1;
+  end
+  until x > 4;
+end.
@@ -0,0 +1,12 @@
+// 读入数字直到 0, 输出它们的和
+var x, sum;
+begin
+  sum := 0;
+  write x
+  while x <> 0 do
+  begin
+    sum := sum + x;
+    write x
+  end;
+  read sum;
+end.
@@ -0,0 +1,26 @@
+// 嵌套过程中的循环通过静态链修改外层的变量
+var total;
+procedure outer(n);
+var k;
+  procedure inner(m);
+  begin
+    while m > 0 do
+    begin
+      k := k + 1;
+      m := m - 1;
+    end;
+  end;
+begin
+  k := 0;
+  repeat
+  begin
+    call inner(n);
+    n := n - 1;
+  end
+  until n = 0;
+  total := k;
+end;
+begin
+  call outer(4);
+  read total;
+end.
@@ -0,0 +1,17 @@
+// 过程中用 var 声明的局部变量 (取自 demo/test1.pl)
+const m=7, n=85;
+var x,y,z,q,r;
+procedure multiply(test,foo);
+	var a,b;
+	begin
+		a:=x; b:=y; z:=0;
+		while b>0 do
+			begin
+				if odd b then z:=z+a;
+				a:=2*a; b:=b/2;
+			end
+	end;
+begin
+	x:=m; y:=n; call multiply(x,y);
+	read x, z;
+end.
@@ -0,0 +1,25 @@
+// 递归和在循环中调用过程
+var r, i;
+procedure fib(n);
+var a, b;
+begin
+  if n < 2 then
+    r := n;
+  else
+  begin
+    call fib(n - 1);
+    a := r;
+    call fib(n - 2);
+    b := r;
+    r := a + b;
+  end
+end;
+begin
+  i := 0;
+  while i < 10 do
+  begin
+    call fib(i);
+    read r;
+    i := i + 1;
+  end
+end.
@@ -0,0 +1,16 @@
+// repeat 循环中的 while 和 if
+var n, steps;
+begin
+  n := 27;
+  steps := 0;
+  repeat
+  begin
+    if odd n then
+      n := 3 * n + 1;
+    else
+      n := n / 2;
+    steps := steps + 1;
+  end
+  until n = 1;
+  read steps;
+end.
@@ -0,0 +1,17 @@
+// 嵌套的 while 循环: 乘法表的对角线和
+var i, j, sum;
+begin
+  i := 1;
+  sum := 0;
+  while i <= 5 do
+  begin
+    j := 1;
+    while j <= i do
+    begin
+      sum := sum + i * j;
+      j := j + 1;
+    end;
+    i := i + 1;
+  end;
+  read sum;
+end.
@@ -0,0 +1,12 @@
+import "lib/math.pl";
+
+var result;
+
+begin
+  call gcd(84, 36);
+  call show;
+  call power(3, 4);
+  call show;
+  result := 7;
+  read result;
+end.
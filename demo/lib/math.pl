@@ -0,0 +1,30 @@
+import "print.pl";
+
+var result;
+
+procedure gcd(a, b);
+var t;
+begin
+  while b <> 0 do
+  begin
+    t := b;
+    b := a - a / b * b;
+    a := t;
+  end;
+  result := a;
+end;
+
+procedure power(x, n);
+begin
+  result := 1;
+  while n > 0 do
+  begin
+    result := result * x;
+    n := n - 1;
+  end;
+end;
+
+procedure show;
+begin
+  call print(result);
+end;
@@ -0,0 +1,6 @@
+const newline = 0;
+
+procedure print(x);
+begin
+  read x;
+end;
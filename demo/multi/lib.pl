@@ -0,0 +1,12 @@
+const base = 10;
+var total;
+
+procedure add(n);
+begin
+  total := total + n;
+end;
+
+procedure scale(n);
+begin
+  total := total * n;
+end;
@@ -0,0 +1,12 @@
+var i;
+begin
+  i := 1;
+  total := base;
+  while i <= 3 do
+  begin
+    call add(i);
+    i := i + 1;
+  end;
+  call scale(2);
+  read total;
+end.
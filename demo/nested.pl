@@ -0,0 +1,22 @@
+var r;
+procedure outer(n, acc);
+    procedure inner(k);
+        procedure add;
+        begin
+            acc := acc + n;
+        end;
+    begin
+        if k > 0 then
+        begin
+            call add;
+            call inner(k - 1);
+        end
+    end;
+begin
+    call inner(n);
+    r := acc;
+end;
+begin
+    call outer(4, 1);
+    read r;
+end.
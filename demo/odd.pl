@@ -0,0 +1,13 @@
+var x, n;
+begin
+    x := -3;
+    n := 0;
+    repeat
+    begin
+        if odd x then read x; else read n;
+        x := x + 1;
+    end
+    until x > 3;
+    if odd -5 then read n;
+    if odd 2 * x + 1 then read x;
+end.
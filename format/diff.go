@@ -0,0 +1,140 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// 差异中每处修改前后保留的上下文行数
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' ', '-' 或 '+'
+	text string
+}
+
+// Diff 按行比较 old 和 new, 返回 unified 格式的差异, 两者相同时返回 nil
+func Diff(oldName, newName string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	lines := diffLines(splitLines(string(old)), splitLines(string(new)))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+
+	// oldLine, newLine 为 lines[i] 之前的行数
+	oldLine, newLine := 0, 0
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// 一段修改, 前后各带上下文; 两段修改之间的上下文不超过 2*diffContext 行时合并
+		start := i
+		for start > 0 && i-start < diffContext && lines[start-1].op == ' ' {
+			start--
+		}
+		end, same := i, 0
+		for end < len(lines) && same <= 2*diffContext {
+			if lines[end].op == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+			end++
+		}
+		if same > diffContext {
+			end -= same - diffContext
+		}
+
+		oldStart, newStart := oldLine-(i-start), newLine-(i-start)
+		var oldCount, newCount int
+		var hunk bytes.Buffer
+		for _, l := range lines[start:end] {
+			if l.op != '+' {
+				oldCount++
+			}
+			if l.op != '-' {
+				newCount++
+			}
+			hunk.WriteByte(l.op)
+			hunk.WriteString(l.text)
+			hunk.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		buf.Write(hunk.Bytes())
+
+		for _, l := range lines[i:end] {
+			if l.op != '+' {
+				oldLine++
+			}
+			if l.op != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return buf.Bytes()
+}
+
+// hunkRange 返回差异块的行范围, 行号从 1 开始, 空范围的行号为其前一行
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines 用最长公共子序列求出把 a 变为 b 的逐行修改
+func diffLines(a, b []string) []diffLine {
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列的长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,38 @@
+// Package format 把语法树重新输出为统一格式的 pl/0 源代码.
+//
+// 格式化的结果与源代码的语法树相同, 对格式化后的结果再次格式化不会有任何变化.
+package format
+
+import (
+	"bytes"
+	"io"
+	"pl0Compiler/ast"
+	"pl0Compiler/parser"
+	"pl0Compiler/token"
+)
+
+// Source 格式化一个 pl/0 源文件, 源代码有语法错误时返回错误
+func Source(fileName string, src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	program, err := parser.ParseFile(fset, fileName, string(src))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := Node(&buf, fset, program); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Node 把单个文件的语法树按统一的格式输出, 注释保留在原来的位置附近.
+// begin/end 之间的语句缩进一层, 每行一条语句, 源代码中的多个空行合并为一个.
+func Node(w io.Writer, fset *token.FileSet, program *ast.Program) error {
+	p := &printer{fset: fset}
+	for _, g := range program.Comments {
+		p.comments = append(p.comments, g.List...)
+	}
+	p.program(program)
+	_, err := w.Write(p.buf.Bytes())
+	return err
+}
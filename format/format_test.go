@@ -0,0 +1,118 @@
+package format_test
+
+import (
+	"os"
+	"path/filepath"
+	"pl0Compiler/format"
+	"testing"
+)
+
+// TestIdempotent 检查对 demo 中的每个文件, 格式化的结果再次格式化不变
+func TestIdempotent(t *testing.T) {
+	var files []string
+	for _, pattern := range []string{"*.pl", "corpus/*.pl", "lib/*.pl", "multi/*.pl"} {
+		m, err := filepath.Glob(filepath.Join("../demo", pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, m...)
+	}
+	if len(files) == 0 {
+		t.Fatal("no demo files")
+	}
+	for _, fileName := range files {
+		src, err := os.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		once, err := format.Source(fileName, src)
+		if err != nil {
+			t.Errorf("%s: %v", fileName, err)
+			continue
+		}
+		twice, err := format.Source(fileName, once)
+		if err != nil {
+			t.Errorf("%s: formatted source: %v", fileName, err)
+			continue
+		}
+		if string(once) != string(twice) {
+			t.Errorf("%s: not idempotent\nfirst:\n%s\nsecond:\n%s", fileName, once, twice)
+		}
+	}
+}
+
+func TestEmptyBody(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{
+			"var x;\nbegin\n  if x = 1 then ;\n  x := 2;\nend.\n",
+			"var x;\nbegin\n\tif x = 1 then ;\n\tx := 2;\nend.\n",
+		},
+		{
+			"var x;\nbegin\n  if x = 1 then ; (* c *)\n  x := 2;\nend.\n",
+			"var x;\nbegin\n\tif x = 1 then ; (* c *)\n\tx := 2;\nend.\n",
+		},
+		{
+			"var x;\nbegin\n  while x = 1 do ;\n  repeat ; until x = 1;\n  begin ; end;\nend.\n",
+			"var x;\nbegin\n\twhile x = 1 do ;\n\trepeat ; until x = 1;\n\tbegin\n\tend;\nend.\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := format.Source("test.pl", []byte(tt.src))
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%q:\ngot:\n%s\nwant:\n%s", tt.src, got, tt.want)
+		}
+	}
+}
+
+// TestComments 检查注释在 if/else, 循环体和表达式中留在原来的位置
+func TestComments(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{
+			"var x;\nbegin\n  if x > 0 then read x; // a\n  else read x; // b\n  x := 1;\nend.\n",
+			"var x;\nbegin\n\tif x > 0 then read x; // a\n\telse read x; // b\n\tx := 1;\nend.\n",
+		},
+		{
+			"var x;\nbegin\n  if x > 0 then read x; (* a *) else read x;\nend.\n",
+			"var x;\nbegin\n\tif x > 0 then read x; (* a *) else read x;\nend.\n",
+		},
+		{
+			"var x;\nbegin\n  if x > 0 then begin\n    read x; // in\n  end // after\n  else\n    // before\n    read x;\nend.\n",
+			"var x;\nbegin\n\tif x > 0 then begin\n\t\tread x; // in\n\tend // after\n\telse\n\t\t// before\n\t\tread x;\nend.\n",
+		},
+		{
+			"var x;\nbegin\n  while x > 0 do x := x - 1; // c\n  while x < 9 do\n    // doc\n    x := x + 1;\n  repeat x := x + 1; // r\n  until x > 3;\nend.\n",
+			"var x;\nbegin\n\twhile x > 0 do x := x - 1; // c\n\twhile x < 9 do\n\t\t// doc\n\t\tx := x + 1;\n\trepeat x := x + 1; // r\n\tuntil x > 3;\nend.\n",
+		},
+		{
+			"var x;\nbegin\n  x := 1 + (* two *) 2;\n  x := 1 +\n    // three\n    3;\n  x := (1 + // one\n    2) * 3;\n  read x;\nend.\n",
+			"var x;\nbegin\n\tx := 1 + (* two *) 2;\n\tx := 1 +\n\t\t// three\n\t\t3;\n\tx := (1 + // one\n\t\t2) * 3;\n\tread x;\nend.\n",
+		},
+		{
+			"const c = 2 (* two *) * 3;\nbegin\nend.\n",
+			"const c = 2 (* two *) * 3;\nbegin\nend.\n",
+		},
+	}
+	for _, tt := range tests {
+		got, err := format.Source("test.pl", []byte(tt.src))
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%q:\ngot:\n%s\nwant:\n%s", tt.src, got, tt.want)
+			continue
+		}
+		again, err := format.Source("test.pl", got)
+		if err != nil || string(again) != string(got) {
+			t.Errorf("%q: formatting again = %q, %v", tt.src, again, err)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"pl0Compiler/ast"
+	"pl0Compiler/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type printer struct {
+	fset     *token.FileSet
+	buf      bytes.Buffer
+	indent   int
+	comments []*ast.Comment // 尚未输出的注释, 按位置排序
+	lastLine int            // 最近输出的内容在源代码中的结束行
+	curLine  int            // 最近开始输出的结点在源代码中的行
+	opening  bool           // 刚开始一个块, 块首不保留空行
+	last     token.Pos      // 语句中最近输出的记号或注释的位置, 用于判断注释是否另起一行
+}
+
+// program 按源代码中的顺序输出全部声明和程序入口
+func (p *printer) program(program *ast.Program) {
+	var decls []ast.Node
+	for _, d := range program.Imports {
+		decls = append(decls, d)
+	}
+	for _, d := range program.Const {
+		decls = append(decls, d)
+	}
+	for _, d := range program.Globals {
+		decls = append(decls, d)
+	}
+	for _, d := range program.Funcs {
+		decls = append(decls, d)
+	}
+	if program.Stmt != nil {
+		decls = append(decls, program.Stmt)
+	}
+	sort.SliceStable(decls, func(i, j int) bool {
+		return decls[i].Pos() < decls[j].Pos()
+	})
+
+	for _, d := range decls {
+		p.startLine(d.Pos())
+		if block, ok := d.(*ast.BlockStmt); ok {
+			p.block(block)
+			p.print(".")
+		} else {
+			p.decl(d)
+		}
+		p.endLine(d.End())
+	}
+
+	for len(p.comments) != 0 {
+		p.comment()
+	}
+}
+
+func (p *printer) decl(d ast.Node) {
+	switch d := d.(type) {
+	case *ast.ImportDecl:
+		p.print("import " + strconv.Quote(d.Path) + ";")
+	case *ast.ConstDecl:
+		p.print("const ")
+		for i, def := range d.Definition {
+			if i != 0 {
+				p.print(", ")
+			}
+			p.print(def.Target.Name + " = ")
+			p.last = def.Target.NamePos
+			p.expr(def.Value)
+		}
+		p.print(";")
+	case *ast.VarDecl:
+		p.print("var ")
+		p.idents(d.Names)
+		p.print(";")
+	case *ast.ProcDecl:
+		p.proc(d)
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", d))
+	}
+}
+
+// proc 输出过程声明, 局部变量和嵌套的过程缩进一层, 过程体与 procedure 对齐
+func (p *printer) proc(d *ast.ProcDecl) {
+	p.print("procedure " + d.Name)
+	header := d.NamePos
+	if d.Params != nil && d.Params.Opening.IsValid() {
+		p.print("(")
+		for i, field := range d.Params.List {
+			if i != 0 {
+				p.print(", ")
+			}
+			p.print(field.Name.Name)
+		}
+		p.print(")")
+		header = d.Params.Closing
+	}
+	p.print(";")
+	p.endLine(header)
+
+	p.indent++
+	p.opening = true
+	if d.VarDecl != nil {
+		p.startLine(d.VarDecl.Pos())
+		p.decl(d.VarDecl)
+		p.endLine(d.VarDecl.End())
+	}
+	for _, fn := range d.Funcs {
+		p.startLine(fn.Pos())
+		p.decl(fn)
+		p.endLine(fn.End())
+	}
+	p.indent--
+
+	if d.Body != nil {
+		p.startLine(d.Body.Pos())
+		p.block(d.Body)
+	}
+	p.print(";")
+}
+
+// block 输出 begin ... end, 不包括其后的 ';' 或 '.'
+func (p *printer) block(b *ast.BlockStmt) {
+	p.print("begin")
+	p.endLine(b.BeginPos)
+
+	p.indent++
+	p.opening = true
+	for _, s := range b.List {
+		if s == nil {
+			continue
+		}
+		p.startLine(s.Pos())
+		p.stmt(s)
+		// 赋值, call 和 read 语句自带 ';', 其它语句用 ';' 分隔
+		if !bytes.HasSuffix(p.buf.Bytes(), []byte(";")) {
+			p.print(";")
+		}
+		p.endLine(s.End())
+	}
+	p.leading(b.EndPos)
+	p.indent--
+	p.opening = false
+
+	p.print("end")
+}
+
+// body 输出 if/while/repeat 的语句体, 没有 begin 的语句体只有一条语句, 与关键字在同一行
+func (p *printer) body(b *ast.BlockStmt) {
+	if b.BeginPos.IsValid() {
+		p.print(" ")
+		p.block(b)
+		return
+	}
+	if len(b.List) == 0 || b.List[0] == nil {
+		p.print(" ;")
+		return
+	}
+	p.inline(b.List[0].Pos(), p.indent+1)
+	p.blank()
+	p.stmt(b.List[0])
+}
+
+// keyword 输出语句中间的关键字, 例如 else 和 until.
+// 关键字之前的注释留在原来的位置, '//' 注释之后关键字另起一行.
+func (p *printer) keyword(pos, end token.Pos, text string) {
+	p.last = end
+	p.inline(pos, p.indent)
+	p.blank()
+	p.print(text)
+	p.last = pos
+}
+
+func (p *printer) stmt(s ast.Stmt) {
+	p.last = s.Pos()
+	switch s := s.(type) {
+	case *ast.BlockStmt:
+		p.block(s)
+	case *ast.VarDecl:
+		p.decl(s)
+	case *ast.AssignStmt:
+		p.print(s.Target.Name + " := ")
+		p.expr(s.Value)
+		p.print(";")
+	case *ast.CallStmt:
+		p.print("call " + s.ProcedureName.Name)
+		if s.Lparen.IsValid() {
+			p.print("(")
+			for i, arg := range s.Args {
+				if i != 0 {
+					p.print(", ")
+				}
+				p.expr(arg)
+			}
+			p.print(")")
+		}
+		p.print(";")
+	case *ast.IOStmt:
+		p.print(s.Type.String() + " ")
+		for i, field := range s.Params.List {
+			if i != 0 {
+				p.print(", ")
+			}
+			p.print(field.Name.Name)
+		}
+		// write 只有一个参数, 不带 ';'
+		if s.Type == token.READ {
+			p.print(";")
+		}
+	case *ast.IfStmt:
+		p.print("if ")
+		p.expr(s.Cond)
+		p.print(" then")
+		p.body(s.Body)
+		if s.Else != nil {
+			p.keyword(s.ElsePos, s.Body.End(), "else")
+			switch e := s.Else.(type) {
+			case *ast.BlockStmt:
+				p.body(e)
+			default:
+				p.inline(e.Pos(), p.indent+1)
+				p.blank()
+				p.stmt(e)
+			}
+		}
+	case *ast.WhileStmt:
+		p.print("while ")
+		p.expr(s.Cond)
+		p.print(" do")
+		p.body(s.Body)
+	case *ast.RepeatStmt:
+		p.print("repeat")
+		p.body(s.Body)
+		p.keyword(s.UntilPos, s.Body.End(), "until ")
+		p.expr(s.Cond)
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", s))
+	}
+}
+
+// expr 输出表达式, 表达式中的注释留在原来的位置
+func (p *printer) expr(x ast.Expr) {
+	p.inline(x.Pos(), p.indent+1)
+	switch x := x.(type) {
+	case *ast.Ident:
+		p.print(x.Name)
+	case *ast.Number:
+		p.print(strconv.Itoa(x.Value))
+	case *ast.BinaryExpr:
+		p.expr(x.X)
+		p.inline(x.OpPos, p.indent+1)
+		p.blank()
+		p.print(x.Op.String() + " ")
+		p.last = x.OpPos
+		p.expr(x.Y)
+	case *ast.UnaryExpr:
+		if x.Op == token.ODD {
+			p.print("odd ")
+		} else {
+			p.print(x.Op.String())
+		}
+		p.last = x.OpPos
+		p.expr(x.X)
+	case *ast.ParenExpr:
+		p.print("(")
+		p.expr(x.X)
+		p.print(")")
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", x))
+	}
+	p.last = x.End()
+}
+
+func (p *printer) idents(list []*ast.Ident) {
+	for i, ident := range list {
+		if i != 0 {
+			p.print(", ")
+		}
+		p.print(ident.Name)
+	}
+}
+
+// print 输出 s, 在行首时先输出缩进
+func (p *printer) print(s string) {
+	if b := p.buf.Bytes(); len(b) == 0 || b[len(b)-1] == '\n' {
+		p.buf.WriteString(strings.Repeat("\t", p.indent))
+	}
+	p.buf.WriteString(s)
+}
+
+// blank 在行首之外输出一个空格, 已经有空格时不输出
+func (p *printer) blank() {
+	if b := p.buf.Bytes(); len(b) != 0 && !strings.ContainsRune(" \t\n(", rune(b[len(b)-1])) {
+		p.buf.WriteByte(' ')
+	}
+}
+
+// wrap 去掉行尾的空格后换行, 下一行缩进 indent 层
+func (p *printer) wrap(indent int) {
+	p.buf.Truncate(len(bytes.TrimRight(p.buf.Bytes(), " ")))
+	p.buf.WriteByte('\n')
+	p.buf.WriteString(strings.Repeat("\t", indent))
+}
+
+func (p *printer) line(pos token.Pos) int {
+	return p.fset.Position(pos).Line
+}
+
+// startLine 开始输出位于 pos 的结点: 先输出其前面的注释, 并保留源代码中的空行
+func (p *printer) startLine(pos token.Pos) {
+	p.leading(pos)
+	p.curLine = p.line(pos)
+	p.space(p.curLine)
+}
+
+// endLine 结束位于 end 的结点所在的行, 同一行中的注释接在行尾.
+// end 无效时结点视为在开始的行结束.
+func (p *printer) endLine(end token.Pos) {
+	line := p.curLine
+	if end.IsValid() {
+		line = p.line(end)
+	}
+	if line > p.lastLine {
+		p.lastLine = line
+	}
+	p.trailing(p.lastLine)
+	p.buf.WriteByte('\n')
+}
+
+// space 源代码中与上一个结点之间有空行时输出一个空行
+func (p *printer) space(line int) {
+	if p.buf.Len() != 0 && !p.opening && line-p.lastLine > 1 {
+		p.buf.WriteByte('\n')
+	}
+	p.opening = false
+}
+
+// leading 输出位于 pos 之前尚未输出的注释, 每个注释独占一行
+func (p *printer) leading(pos token.Pos) {
+	for len(p.comments) != 0 && p.comments[0].Pos() < pos {
+		p.comment()
+	}
+}
+
+func (p *printer) comment() {
+	c := p.comments[0]
+	p.comments = p.comments[1:]
+	p.space(p.line(c.Pos()))
+	p.print(c.Text)
+	p.buf.WriteByte('\n')
+	p.lastLine = p.line(c.End())
+}
+
+// inline 输出语句中位于 pos 之前的注释, 注释留在语句中原来的位置.
+// 注释在源代码中另起一行时先换行, '//' 注释之后换行, 换行后缩进 indent 层.
+func (p *printer) inline(pos token.Pos, indent int) {
+	for len(p.comments) != 0 && p.comments[0].Pos() < pos {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		if p.last.IsValid() && p.line(c.Pos()) > p.line(p.last) {
+			p.wrap(indent)
+		}
+		p.blank()
+		p.print(c.Text)
+		if strings.HasPrefix(c.Text, "//") {
+			p.wrap(indent)
+		} else {
+			p.buf.WriteByte(' ')
+		}
+		p.last = c.End()
+		if line := p.line(c.End()); line > p.lastLine {
+			p.lastLine = line
+		}
+	}
+}
+
+// trailing 输出开始于 line 行或之前的注释, 接在当前行的末尾
+func (p *printer) trailing(line int) {
+	lineComment := false
+	for len(p.comments) != 0 && p.line(p.comments[0].Pos()) <= line {
+		c := p.comments[0]
+		p.comments = p.comments[1:]
+		if lineComment {
+			// '//' 注释延续到行尾, 之后的注释只能另起一行
+			p.buf.WriteByte('\n')
+			p.print(c.Text)
+		} else {
+			p.buf.WriteString(" " + c.Text)
+		}
+		lineComment = strings.HasPrefix(c.Text, "//")
+		if line := p.line(c.End()); line > p.lastLine {
+			p.lastLine = line
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package interp
+
+import "pl0Compiler/ast"
+
+// ObjKind 运行时对象的种类
+type ObjKind int
+
+const (
+	Var   ObjKind = iota // 变量或参数
+	Const                // 常量
+	Proc                 // 过程
+)
+
+// Object 运行时对象
+type Object struct {
+	Name  string
+	Kind  ObjKind
+	Value int64         // 变量/常量的值
+	Proc  *ast.ProcDecl // 过程定义
+	Env   *Env          // 过程定义时所在的环境
+}
+
+// Env 运行时的作用域
+type Env struct {
+	Outer   *Env
+	Objects map[string]*Object
+}
+
+func NewEnv(outer *Env) *Env {
+	return &Env{outer, make(map[string]*Object)}
+}
+
+func (e *Env) Lookup(name string) (*Env, *Object) {
+	for ; e != nil; e = e.Outer {
+		if obj := e.Objects[name]; obj != nil {
+			return e, obj
+		}
+	}
+	return nil, nil
+}
+
+func (e *Env) Insert(obj *Object) (alt *Object) {
+	if alt = e.Objects[obj.Name]; alt == nil {
+		e.Objects[obj.Name] = obj
+	}
+	return
+}
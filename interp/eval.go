@@ -0,0 +1,78 @@
+package interp
+
+import (
+	"fmt"
+	"pl0Compiler/ast"
+	"pl0Compiler/token"
+)
+
+func (p *Interp) evalExpr(env *Env, expr ast.Expr) int64 {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		_, obj := env.Lookup(expr.Name)
+		if obj == nil {
+			p.errorf(expr.NamePos, "var %s undefined", expr.Name)
+		}
+		if obj.Kind == Proc {
+			p.errorf(expr.NamePos, "proc %s used as value", expr.Name)
+		}
+		return obj.Value
+	case *ast.Number:
+		return p.wrap(int64(expr.Value))
+	case *ast.BinaryExpr:
+		x, y := p.evalExpr(env, expr.X), p.evalExpr(env, expr.Y)
+		switch expr.Op {
+		case token.ADD:
+			return p.wrap(x + y)
+		case token.SUB:
+			return p.wrap(x - y)
+		case token.MUL:
+			return p.wrap(x * y)
+		case token.DIV:
+			if y == 0 {
+				p.errorf(expr.OpPos, "division by zero")
+			}
+			return p.wrap(x / y)
+		case token.MOD:
+			if y == 0 {
+				p.errorf(expr.OpPos, "division by zero")
+			}
+			return p.wrap(x % y)
+
+		case token.EQL: // =
+			return bool2int(x == y)
+		case token.NEQ: // <>
+			return bool2int(x != y)
+		case token.LSS: // <
+			return bool2int(x < y)
+		case token.LEQ: // <=
+			return bool2int(x <= y)
+		case token.GTR: // >
+			return bool2int(x > y)
+		case token.GEQ: // >=
+			return bool2int(x >= y)
+		default:
+			panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
+		}
+	case *ast.UnaryExpr:
+		switch expr.Op {
+		case token.SUB:
+			return p.wrap(-p.evalExpr(env, expr.X))
+		case token.ODD:
+			return bool2int(p.evalExpr(env, expr.X)&1 != 0)
+		}
+		return p.evalExpr(env, expr.X)
+	case *ast.ParenExpr:
+		return p.evalExpr(env, expr.X)
+
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
+	}
+}
+
+func bool2int(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
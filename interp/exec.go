@@ -0,0 +1,178 @@
+package interp
+
+import (
+	"fmt"
+	"pl0Compiler/ast"
+	"pl0Compiler/token"
+)
+
+func (p *Interp) execStmt(env *Env, stmt ast.Stmt) {
+	switch stmt.(type) {
+	case nil, *ast.BlockStmt, *ast.VarDecl:
+	default:
+		p.step(env, stmt)
+	}
+
+	switch stmt := stmt.(type) {
+	case nil:
+		// 空语句
+	case *ast.VarDecl:
+		p.declareVar(env, stmt)
+	case *ast.AssignStmt:
+		p.execStmtAssign(env, stmt)
+	case *ast.IfStmt:
+		p.execStmtIf(env, stmt)
+	case *ast.WhileStmt:
+		for p.evalExpr(env, stmt.Cond) != 0 {
+			p.execStmt(env, stmt.Body)
+		}
+	case *ast.RepeatStmt:
+		for {
+			p.execStmt(env, stmt.Body)
+			if p.evalExpr(env, stmt.Cond) != 0 {
+				break
+			}
+		}
+	case *ast.BlockStmt:
+		blockEnv := NewEnv(env)
+		for _, x := range stmt.List {
+			p.execStmt(blockEnv, x)
+		}
+	case *ast.ExprStmt:
+		p.evalExpr(env, stmt.X)
+	case *ast.CallStmt:
+		p.execStmtCall(env, stmt)
+	case *ast.IOStmt:
+		p.execIOStmt(env, stmt)
+
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", stmt))
+	}
+}
+
+func (p *Interp) execStmtAssign(env *Env, stmt *ast.AssignStmt) {
+	value := p.evalExpr(env, stmt.Value)
+	obj := p.lookupVar(env, stmt.Target)
+	obj.Value = value
+}
+
+func (p *Interp) execStmtIf(env *Env, stmt *ast.IfStmt) {
+	if p.evalExpr(env, stmt.Cond) != 0 {
+		p.execStmt(env, stmt.Body)
+	} else if stmt.Else != nil {
+		p.execStmt(env, stmt.Else)
+	}
+}
+
+func (p *Interp) execStmtCall(env *Env, stmt *ast.CallStmt) {
+	_, obj := env.Lookup(stmt.ProcedureName.Name)
+	if obj == nil {
+		p.errorf(stmt.ProcedureName.NamePos, "proc %s undefined", stmt.ProcedureName.Name)
+	}
+	if obj.Kind != Proc {
+		p.errorf(stmt.ProcedureName.NamePos, "%s is not a procedure", stmt.ProcedureName.Name)
+	}
+
+	if obj.Proc == nil {
+		p.callBuiltin(env, stmt)
+		return
+	}
+
+	fn := obj.Proc
+	if fn.Body == nil {
+		p.errorf(stmt.ProcedureName.NamePos, "proc %s has no body", fn.Name)
+	}
+	if len(stmt.Args) != len(fn.Params.List) {
+		p.errorf(stmt.ProcedureName.NamePos, "proc %s expects %d arguments, got %d",
+			fn.Name, len(fn.Params.List), len(stmt.Args))
+	}
+
+	// 实参在调用者的环境中求值, 形参在过程定义处的环境中可见
+	args := make([]int64, len(stmt.Args))
+	for i, arg := range stmt.Args {
+		args[i] = p.evalExpr(env, arg)
+	}
+
+	procEnv := NewEnv(obj.Env)
+	for i, arg := range fn.Params.List {
+		procEnv.Insert(&Object{
+			Name:  arg.Name.Name,
+			Kind:  Var,
+			Value: args[i],
+		})
+	}
+	if fn.VarDecl != nil {
+		p.declareVar(procEnv, fn.VarDecl)
+	}
+	for _, sub := range fn.Funcs {
+		procEnv.Insert(&Object{
+			Name: sub.Name,
+			Kind: Proc,
+			Proc: sub,
+			Env:  procEnv,
+		})
+	}
+
+	p.frames = append(p.frames, &Frame{Proc: fn, Call: stmt, Env: procEnv})
+	defer func() { p.frames = p.frames[:len(p.frames)-1] }()
+
+	for _, x := range fn.Body.List {
+		p.execStmt(procEnv, x)
+	}
+}
+
+// callBuiltin 执行内置过程 println 和 exit
+func (p *Interp) callBuiltin(env *Env, stmt *ast.CallStmt) {
+	name := stmt.ProcedureName.Name
+	if len(stmt.Args) != 1 {
+		p.errorf(stmt.ProcedureName.NamePos, "proc %s expects 1 arguments, got %d", name, len(stmt.Args))
+	}
+	x := p.evalExpr(env, stmt.Args[0])
+	switch name {
+	case "println":
+		fmt.Fprintf(p.stdout, "%d\n", x)
+	case "exit":
+		panic(exit{code: int(x)})
+	default:
+		panic(fmt.Sprintf("unknown builtin: %s", name))
+	}
+}
+
+// step 在执行语句前记录调用栈中的位置, 然后调用 Hook
+func (p *Interp) step(env *Env, stmt ast.Stmt) {
+	if n := len(p.frames); n > 0 {
+		p.frames[n-1].Env, p.frames[n-1].Stmt = env, stmt
+	}
+	if p.hook != nil {
+		p.hook(stmt, env)
+	}
+}
+
+func (p *Interp) execIOStmt(env *Env, stmt *ast.IOStmt) {
+	switch stmt.Type {
+	case token.READ:
+		// 与编译器保持一致: read 输出参数的值
+		for _, param := range stmt.Params.List {
+			_, _ = fmt.Fprintf(p.stdout, "%d\n", p.evalExpr(env, param.Name))
+		}
+	case token.WRITE:
+		// 与编译器保持一致: write 从标准输入读取一个整数
+		obj := p.lookupVar(env, stmt.Params.List[0].Name)
+		var x int64
+		if _, err := fmt.Fscan(p.stdin, &x); err != nil {
+			p.errorf(stmt.IOPos, "write %s: %v", obj.Name, err)
+		}
+		obj.Value = p.wrap(x)
+	}
+}
+
+func (p *Interp) lookupVar(env *Env, ident *ast.Ident) *Object {
+	_, obj := env.Lookup(ident.Name)
+	if obj == nil {
+		p.errorf(ident.NamePos, "var %s undefined", ident.Name)
+	}
+	if obj.Kind != Var {
+		p.errorf(ident.NamePos, "cannot assign to %s", ident.Name)
+	}
+	return obj
+}
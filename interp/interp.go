@@ -0,0 +1,264 @@
+package interp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"pl0Compiler/ast"
+	"pl0Compiler/constant"
+	"pl0Compiler/token"
+)
+
+// Interp 直接遍历语法树执行 pl/0 程序的解释器.
+type Interp struct {
+	program *ast.Program
+	stdin   *bufio.Reader
+	stdout  io.Writer
+	globals *Env
+	modules map[*ast.Program]*Env // 导入的模块的全局环境
+
+	frames []*Frame // 调用栈, 最内层的调用在最后
+	hook   Hook
+	bits   int // 整数的位数, 与编译器的 IntWidth 一致
+}
+
+// Frame 一次过程调用的活动记录, 主程序的 Proc 和 Call 为 nil
+type Frame struct {
+	Proc *ast.ProcDecl
+	Call *ast.CallStmt // 调用处的语句
+	Env  *Env          // 正在执行的语句所在的环境, 可以是过程中的块
+	Stmt ast.Stmt      // 正在执行的语句, 调用者停在 call 语句上
+}
+
+// Hook 在执行每条语句前调用, 用于调试器. 块和变量声明不触发 Hook.
+type Hook func(stmt ast.Stmt, env *Env)
+
+// Error 运行时错误
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	if e.Pos.Filename != "" || e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Msg
+	}
+	return e.Msg
+}
+
+// ExitError 程序以非零的退出码调用了 exit
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string { return fmt.Sprintf("exit status %d", e.Code) }
+
+// ExitCode 返回退出码, 与 exec.ExitError 一致
+func (e *ExitError) ExitCode() int { return e.Code }
+
+// exit 由内置过程 exit 抛出, 展开整个调用栈
+type exit struct {
+	code int
+}
+
+func NewInterp(program *ast.Program, stdin io.Reader, stdout io.Writer) *Interp {
+	return &Interp{
+		program: program,
+		stdin:   bufio.NewReader(stdin),
+		stdout:  stdout,
+		bits:    32,
+	}
+}
+
+// Run 执行整个程序, 输出与编译后的可执行程序一致.
+func (p *Interp) Run() (err error) {
+	defer runExit(&err)
+	defer catch(&err)
+
+	p.modules = make(map[*ast.Program]*Env)
+	p.globals = p.declareProgram(p.program)
+	p.frames = []*Frame{{Env: p.globals}}
+
+	if p.program.Stmt != nil {
+		p.execStmt(p.globals, p.program.Stmt)
+	}
+	return nil
+}
+
+// runExit 把 exit(0) 视为正常结束
+func runExit(err *error) {
+	if e, ok := (*err).(*ExitError); ok && e.Code == 0 {
+		*err = nil
+	}
+}
+
+// SetHook 设置执行每条语句前调用的函数, hook 为 nil 时取消
+func (p *Interp) SetHook(hook Hook) {
+	p.hook = hook
+}
+
+// SetIntWidth 设置整数的位数, bits 为 32 或 64, 默认为 32
+func (p *Interp) SetIntWidth(bits int) {
+	if bits != 32 && bits != 64 {
+		panic(fmt.Sprintf("invalid int width %d", bits))
+	}
+	p.bits = bits
+}
+
+// Frames 返回当前的调用栈, 第一个是主程序, 最后一个是正在执行的过程.
+// 只在 Hook 中调用才有意义.
+func (p *Interp) Frames() []*Frame {
+	return p.frames
+}
+
+// Declare 在全局环境中声明常量, 变量或过程, 用于交互式执行.
+// 与 Run 不同, 同名的全局对象会被新的声明替换.
+func (p *Interp) Declare(decl ast.Node) (err error) {
+	defer catch(&err)
+	p.setup()
+
+	switch decl := decl.(type) {
+	case *ast.VarDecl:
+		for _, name := range decl.Names {
+			p.globals.Objects[name.Name] = &Object{Name: name.Name, Kind: Var}
+		}
+	case *ast.ConstDecl:
+		for _, def := range decl.Definition {
+			p.globals.Objects[def.Target.Name] = &Object{
+				Name:  def.Target.Name,
+				Kind:  Const,
+				Value: p.constValue(p.globals, def),
+			}
+		}
+	case *ast.ProcDecl:
+		p.globals.Objects[decl.Name] = &Object{Name: decl.Name, Kind: Proc, Proc: decl, Env: p.globals}
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", decl))
+	}
+	return nil
+}
+
+// Exec 在全局环境中执行一条语句, 用于交互式执行.
+// 语句调用了 exit 时返回 *ExitError, 退出码为 0 时也是如此.
+func (p *Interp) Exec(stmt ast.Stmt) (err error) {
+	defer catch(&err)
+	p.setup()
+
+	p.execStmt(p.globals, stmt)
+	return nil
+}
+
+// Globals 返回全局环境, 程序还没有开始执行时为 nil
+func (p *Interp) Globals() *Env {
+	return p.globals
+}
+
+// setup 在第一次交互式执行前声明程序的全局对象
+func (p *Interp) setup() {
+	if p.globals == nil {
+		p.modules = make(map[*ast.Program]*Env)
+		p.globals = p.declareProgram(p.program)
+	}
+}
+
+// catch 把运行时错误和 exit 的 panic 转为返回的错误
+func catch(err *error) {
+	if r := recover(); r != nil {
+		switch e := r.(type) {
+		case *Error:
+			*err = e
+			return
+		case exit:
+			*err = &ExitError{Code: e.code}
+			return
+		}
+		panic(r)
+	}
+}
+
+// universe 内置过程所在的环境, 位于所有全局环境之外. 内置过程的 Proc 为 nil.
+var universe = &Env{Objects: map[string]*Object{
+	"println": {Name: "println", Kind: Proc},
+	"exit":    {Name: "exit", Kind: Proc},
+}}
+
+// declareProgram 声明程序的全局对象, 返回全局环境. 导入的名字位于全局环境之外.
+func (p *Interp) declareProgram(program *ast.Program) *Env {
+	imports := NewEnv(universe)
+	for _, imp := range program.Imports {
+		for _, obj := range p.declareModule(imp.Module).Objects {
+			imports.Insert(obj)
+		}
+	}
+
+	env := NewEnv(imports)
+	for _, g := range program.Globals {
+		p.declareVar(env, g)
+	}
+
+	for _, c := range program.Const {
+		for _, def := range c.Definition {
+			env.Insert(&Object{
+				Name:  def.Target.Name,
+				Kind:  Const,
+				Value: p.constValue(env, def),
+			})
+		}
+	}
+
+	for _, fn := range program.Funcs {
+		env.Insert(&Object{
+			Name: fn.Name,
+			Kind: Proc,
+			Proc: fn,
+			Env:  env,
+		})
+	}
+	return env
+}
+
+// declareModule 声明导入的模块, 模块的变量在整个程序中只有一份
+func (p *Interp) declareModule(module *ast.Program) *Env {
+	if env, ok := p.modules[module]; ok {
+		return env
+	}
+	env := p.declareProgram(module)
+	p.modules[module] = env
+	return env
+}
+
+// constValue 对常量定义求值, 只能引用 env 中已声明的常量
+func (p *Interp) constValue(env *Env, def *ast.DefineStmt) int64 {
+	value, err := constant.EvalWidth(def.Value, p.bits, func(name string) (int64, bool) {
+		_, obj := env.Lookup(name)
+		if obj == nil || obj.Kind != Const {
+			return 0, false
+		}
+		return obj.Value, true
+	})
+	if err != nil {
+		p.errorf(err.(*constant.Error).Pos, "const %s: %v", def.Target.Name, err)
+	}
+	return value
+}
+
+func (p *Interp) declareVar(env *Env, decl *ast.VarDecl) {
+	for _, name := range decl.Names {
+		env.Insert(&Object{
+			Name: name.Name,
+			Kind: Var,
+		})
+	}
+}
+
+func (p *Interp) errorf(pos token.Pos, format string, args ...interface{}) {
+	panic(&Error{
+		Pos: p.program.FileSet.Position(pos),
+		Msg: fmt.Sprintf(format, args...),
+	})
+}
+
+// wrap 按整数的位数截断
+func (p *Interp) wrap(x int64) int64 {
+	return constant.Wrap(x, p.bits)
+}
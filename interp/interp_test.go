@@ -0,0 +1,132 @@
+package interp_test
+
+import (
+	"bytes"
+	"errors"
+	"pl0Compiler/build"
+	"pl0Compiler/interp"
+	"strings"
+	"testing"
+)
+
+// demoInput 是运行 demo 时的标准输入, 供 write 语句读取
+const demoInput = "5 7 9 3 0"
+
+// run 检查 fileNames 组成的程序 (srcs 为 nil 时读取文件) 并用解释器执行
+func run(t *testing.T, fileNames []string, srcs []interface{}, input string) (string, error) {
+	t.Helper()
+	program, err := build.NewContext(nil).Check(fileNames, srcs, nil)
+	if err != nil {
+		t.Fatalf("%v: %v", fileNames, err)
+	}
+	var out bytes.Buffer
+	err = interp.NewInterp(program, strings.NewReader(input), &out).Run()
+	return out.String(), err
+}
+
+func TestDemos(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  string
+	}{
+		{[]string{"../demo/comments.pl"}, "15"},
+		{[]string{"../demo/const.pl"}, "8 5 40 -40 20"},
+		{[]string{"../demo/import.pl"}, "12 81 7"},
+		{[]string{"../demo/nested.pl"}, "17"},
+		{[]string{"../demo/odd.pl"}, "-3 0 -1 0 1 0 3 0 4"},
+		{[]string{"../demo/test2.pl"}, "11"},
+		{[]string{"../demo/multi/main.pl", "../demo/multi/lib.pl"}, "32"},
+		{[]string{"../demo/corpus/blocks.pl"}, "1 1 4 3"},
+		{[]string{"../demo/corpus/empty.pl"}, "0"},
+		{[]string{"../demo/corpus/if_else.pl"}, "-1 -1 -3 -1 -1 -1 0 1 1 1 1 3 1"},
+		{[]string{"../demo/corpus/input.pl"}, "24"},
+		{[]string{"../demo/corpus/nested_loops.pl"}, "10"},
+		{[]string{"../demo/corpus/proc_vars.pl"}, "7 595"},
+		{[]string{"../demo/corpus/recursion.pl"}, "0 1 1 2 3 5 8 13 21 34"},
+		{[]string{"../demo/corpus/repeat.pl"}, "111"},
+		{[]string{"../demo/corpus/while_nested.pl"}, "140"},
+	}
+	for _, tt := range tests {
+		out, err := run(t, tt.files, nil, demoInput)
+		if err != nil {
+			t.Errorf("%v: %v", tt.files, err)
+			continue
+		}
+		if got := strings.Join(strings.Fields(out), " "); got != tt.want {
+			t.Errorf("%v: output = %q, want %q", tt.files, got, tt.want)
+		}
+	}
+}
+
+func TestProcedures(t *testing.T) {
+	src := `
+var x, r;
+procedure add(a, b);
+begin
+  r := a + b;
+end;
+begin
+  x := 5;
+  call add(x, 7);
+  read r;
+  write x;
+  read x;
+end.`
+	out, err := run(t, []string{"test.pl"}, []interface{}{src}, "9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "12\n9\n" {
+		t.Errorf("output = %q, want %q", out, "12\n9\n")
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	src := `
+var x;
+procedure p;
+begin
+  call println(x);
+  if x > 2 then begin call exit(x); end;
+  x := x + 1;
+  call p;
+end;
+begin x := 0; call p; end.`
+	out, err := run(t, []string{"test.pl"}, []interface{}{src}, "")
+	var exit *interp.ExitError
+	if !errors.As(err, &exit) || exit.Code != 3 {
+		t.Fatalf("err = %v, want exit status 3", err)
+	}
+	if out != "0\n1\n2\n3\n" {
+		t.Errorf("output = %q, want %q", out, "0\n1\n2\n3\n")
+	}
+
+	src = "begin call println(1); call exit(0); call println(2); end."
+	out, err = run(t, []string{"test.pl"}, []interface{}{src}, "")
+	if err != nil {
+		t.Fatalf("exit(0): %v", err)
+	}
+	if out != "1\n" {
+		t.Errorf("output = %q, want %q", out, "1\n")
+	}
+}
+
+const oddSrc = `
+var x;
+begin
+  x := -3;
+  if odd x then begin call println(1); end else begin call println(0); end;
+  x := -4;
+  if odd x then begin call println(1); end else begin call println(0); end;
+end.`
+
+// TestOddNegative 检查 odd 对负数取最低位: odd(-3) 为真, odd(-4) 为假
+func TestOddNegative(t *testing.T) {
+	out, err := run(t, []string{"test.pl"}, []interface{}{oddSrc}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "1\n0\n" {
+		t.Errorf("output = %q, want %q", out, "1\n0\n")
+	}
+}
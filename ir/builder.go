@@ -0,0 +1,123 @@
+package ir
+
+// Builder 在当前基本块的末尾添加指令
+type Builder struct {
+	block *Block
+}
+
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// SetInsertPoint 之后的指令添加到 b 的末尾
+func (b *Builder) SetInsertPoint(block *Block) {
+	b.block = block
+}
+
+// Block 返回当前的基本块
+func (b *Builder) Block() *Block {
+	return b.block
+}
+
+// Func 返回当前基本块所在的函数
+func (b *Builder) Func() *Func {
+	return b.block.Parent
+}
+
+func (b *Builder) insert(i Instruction) {
+	i.setBlock(b.block)
+	b.block.Insts = append(b.block.Insts, i)
+}
+
+func (b *Builder) newBinOp(op string, x, y Value) *BinOp {
+	i := &BinOp{Op: op, X: x, Y: y}
+	b.insert(i)
+	return i
+}
+
+func (b *Builder) NewAdd(x, y Value) *BinOp  { return b.newBinOp("add", x, y) }
+func (b *Builder) NewSub(x, y Value) *BinOp  { return b.newBinOp("sub", x, y) }
+func (b *Builder) NewMul(x, y Value) *BinOp  { return b.newBinOp("mul", x, y) }
+func (b *Builder) NewSDiv(x, y Value) *BinOp { return b.newBinOp("sdiv", x, y) }
+func (b *Builder) NewSRem(x, y Value) *BinOp { return b.newBinOp("srem", x, y) }
+func (b *Builder) NewAnd(x, y Value) *BinOp  { return b.newBinOp("and", x, y) }
+
+// NewICmp 整数比较, pred 为 eq, ne, slt, sle, sgt, sge
+func (b *Builder) NewICmp(pred string, x, y Value) *ICmp {
+	i := &ICmp{Pred: pred, X: x, Y: y}
+	b.insert(i)
+	return i
+}
+
+func (b *Builder) NewAlloca(elem Type, align int) *Alloca {
+	i := &Alloca{Elem: elem, Align: align}
+	b.insert(i)
+	return i
+}
+
+func (b *Builder) NewLoad(ptr Value, align int) *Load {
+	i := &Load{Ptr: ptr, Align: align}
+	b.insert(i)
+	return i
+}
+
+func (b *Builder) NewStore(val, ptr Value, align int) *Store {
+	i := &Store{Val: val, Ptr: ptr, Align: align}
+	b.insert(i)
+	return i
+}
+
+// NewGEP 计算 ptr 指向的 elem 中字段的地址
+func (b *Builder) NewGEP(elem Type, ptr Value, indices ...Value) *GEP {
+	i := &GEP{Elem: elem, Ptr: ptr, Indices: indices}
+	b.insert(i)
+	return i
+}
+
+// NewExtractValue 取出结构体值 x 的第 index 个字段
+func (b *Builder) NewExtractValue(x Value, index int) *ExtractValue {
+	i := &ExtractValue{X: x, Index: index}
+	b.insert(i)
+	return i
+}
+
+func (b *Builder) newCast(op string, x Value, to Type) *Cast {
+	i := &Cast{Op: op, X: x, To: to}
+	b.insert(i)
+	return i
+}
+
+func (b *Builder) NewZExt(x Value, to Type) *Cast    { return b.newCast("zext", x, to) }
+func (b *Builder) NewTrunc(x Value, to Type) *Cast   { return b.newCast("trunc", x, to) }
+func (b *Builder) NewBitCast(x Value, to Type) *Cast { return b.newCast("bitcast", x, to) }
+
+func (b *Builder) NewCall(callee *Func, args ...Value) *Call {
+	i := &Call{Callee: callee, Args: args}
+	b.insert(i)
+	return i
+}
+
+func (b *Builder) NewBr(target *Block) *Br {
+	i := &Br{Target: target}
+	b.insert(i)
+	return i
+}
+
+func (b *Builder) NewCondBr(cond Value, t, f *Block) *CondBr {
+	i := &CondBr{Cond: cond, True: t, False: f}
+	b.insert(i)
+	return i
+}
+
+// NewRet 返回 x, x 为 nil 时返回 void
+func (b *Builder) NewRet(x Value) *Ret {
+	i := &Ret{X: x}
+	b.insert(i)
+	return i
+}
+
+func (b *Builder) NewUnreachable() *Unreachable {
+	i := &Unreachable{}
+	b.insert(i)
+	return i
+}
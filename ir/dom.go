@@ -0,0 +1,133 @@
+package ir
+
+// domTree 函数的支配树, 只包含从入口可达的基本块
+type domTree struct {
+	idom  map[*Block]*Block // 直接支配者, 入口块的直接支配者是自己
+	order map[*Block]int    // 逆后序编号
+}
+
+// newDomTree 用 Cooper, Harvey 和 Kennedy 的迭代算法计算支配树.
+// 函数必须至少有一个基本块, 且每个基本块都有终结指令.
+func newDomTree(f *Func) *domTree {
+	// 逆后序
+	var post []*Block
+	visited := make(map[*Block]bool)
+	var dfs func(b *Block)
+	dfs = func(b *Block) {
+		visited[b] = true
+		for _, succ := range b.Term().Succs() {
+			if !visited[succ] {
+				dfs(succ)
+			}
+		}
+		post = append(post, b)
+	}
+	entry := f.Blocks[0]
+	dfs(entry)
+
+	t := &domTree{idom: make(map[*Block]*Block), order: make(map[*Block]int)}
+	rpo := make([]*Block, len(post))
+	for i, b := range post {
+		rpo[len(post)-1-i] = b
+		t.order[b] = len(post) - 1 - i
+	}
+
+	preds := make(map[*Block][]*Block)
+	for _, b := range rpo {
+		for _, succ := range b.Term().Succs() {
+			preds[succ] = append(preds[succ], b)
+		}
+	}
+
+	t.idom[entry] = entry
+	for changed := true; changed; {
+		changed = false
+		for _, b := range rpo[1:] {
+			var idom *Block
+			for _, pred := range preds[b] {
+				if t.idom[pred] == nil {
+					continue
+				}
+				if idom == nil {
+					idom = pred
+				} else {
+					idom = t.intersect(pred, idom)
+				}
+			}
+			if t.idom[b] != idom {
+				t.idom[b] = idom
+				changed = true
+			}
+		}
+	}
+	return t
+}
+
+func (t *domTree) intersect(a, b *Block) *Block {
+	for a != b {
+		for t.order[a] > t.order[b] {
+			a = t.idom[a]
+		}
+		for t.order[b] > t.order[a] {
+			b = t.idom[b]
+		}
+	}
+	return a
+}
+
+// reachable 报告 b 是否从入口可达
+func (t *domTree) reachable(b *Block) bool {
+	_, ok := t.idom[b]
+	return ok
+}
+
+// dominates 报告 a 是否支配 b, 两者都必须可达
+func (t *domTree) dominates(a, b *Block) bool {
+	for {
+		if a == b {
+			return true
+		}
+		if t.idom[b] == b {
+			return false
+		}
+		b = t.idom[b]
+	}
+}
+
+// verifyDominance 检查每个值在使用之前定义: 同一个基本块中定义在前,
+// 或者定义所在的基本块支配使用处. 不可达的基本块不检查.
+func (v *verifier) verifyDominance(f *Func) {
+	if len(f.Blocks) == 0 {
+		return
+	}
+	t := newDomTree(f)
+	index := make(map[Instruction]int)
+	for _, b := range f.Blocks {
+		for k, inst := range b.Insts {
+			index[inst] = k
+		}
+	}
+
+	for _, b := range f.Blocks {
+		if !t.reachable(b) {
+			continue
+		}
+		v.block = b
+		for k, inst := range b.Insts {
+			for _, x := range inst.Operands() {
+				def, ok := x.(Instruction)
+				if !ok {
+					continue
+				}
+				db := def.Block()
+				switch {
+				case db == b && index[def] >= k:
+					v.errorf("%s is used before it is defined", def.Ident())
+				case db != b && (!t.reachable(db) || !t.dominates(db, b)):
+					v.errorf("%s defined in %s does not dominate its use", def.Ident(), db.Ident())
+				}
+			}
+		}
+	}
+	v.block = nil
+}
@@ -0,0 +1,202 @@
+package ir
+
+// Instruction 基本块中的指令. 没有结果的指令 (store, 分支) 的类型为 Void.
+type Instruction interface {
+	Value
+	Block() *Block
+	Operands() []Value
+	setBlock(b *Block)
+}
+
+// Terminator 结束基本块的指令
+type Terminator interface {
+	Instruction
+	Succs() []*Block
+}
+
+// inst 指令的公共部分
+type inst struct {
+	Name  string // 结果的名字, 为空时输出前自动编号
+	block *Block
+}
+
+func (i *inst) Ident() string       { return "%" + i.Name }
+func (i *inst) Block() *Block       { return i.block }
+func (i *inst) setBlock(b *Block)   { i.block = b }
+func (i *inst) SetName(name string) { i.Name = name }
+
+// BinOp 二元运算: add, sub, mul, sdiv, srem, and, or, xor
+type BinOp struct {
+	inst
+	Op   string
+	X, Y Value
+}
+
+func (i *BinOp) Type() Type        { return i.X.Type() }
+func (i *BinOp) Operands() []Value { return []Value{i.X, i.Y} }
+
+// ICmp 整数比较, Pred 为 eq, ne, slt, sle, sgt, sge
+type ICmp struct {
+	inst
+	Pred string
+	X, Y Value
+}
+
+func (i *ICmp) Type() Type        { return I1 }
+func (i *ICmp) Operands() []Value { return []Value{i.X, i.Y} }
+
+// Alloca 在栈上分配 Elem
+type Alloca struct {
+	inst
+	Elem  Type
+	Align int
+}
+
+func (i *Alloca) Type() Type        { return NewPointer(i.Elem) }
+func (i *Alloca) Operands() []Value { return nil }
+
+// Load 从 Ptr 读取
+type Load struct {
+	inst
+	Ptr   Value
+	Align int
+}
+
+func (i *Load) Type() Type {
+	if t, ok := i.Ptr.Type().(*PointerType); ok {
+		return t.Elem
+	}
+	return Void
+}
+func (i *Load) Operands() []Value { return []Value{i.Ptr} }
+
+// Store 把 Val 写入 Ptr
+type Store struct {
+	inst
+	Val, Ptr Value
+	Align    int
+}
+
+func (i *Store) Type() Type        { return Void }
+func (i *Store) Operands() []Value { return []Value{i.Val, i.Ptr} }
+
+// GEP 计算 Ptr 指向的 Elem 中字段的地址. 第一个下标按指针偏移,
+// 之后的下标选择结构体的字段或数组的元素.
+type GEP struct {
+	inst
+	Elem    Type
+	Ptr     Value
+	Indices []Value
+}
+
+func (i *GEP) Type() Type {
+	if t := gepType(i.Elem, i.Indices); t != nil {
+		return NewPointer(t)
+	}
+	return Void
+}
+func (i *GEP) Operands() []Value { return append([]Value{i.Ptr}, i.Indices...) }
+
+// gepType 返回下标选中的类型, 下标不合法时返回 nil
+func gepType(elem Type, indices []Value) Type {
+	if len(indices) == 0 {
+		return nil
+	}
+	t := elem
+	for _, index := range indices[1:] {
+		switch st := t.(type) {
+		case *ArrayType:
+			t = st.Elem
+		case *StructType:
+			c, ok := index.(*Const)
+			if !ok || c.Value < 0 || int(c.Value) >= len(st.Fields) {
+				return nil
+			}
+			t = st.Fields[c.Value]
+		default:
+			return nil
+		}
+	}
+	return t
+}
+
+// ExtractValue 取出结构体值 X 的第 Index 个字段
+type ExtractValue struct {
+	inst
+	X     Value
+	Index int
+}
+
+func (i *ExtractValue) Type() Type {
+	if t, ok := i.X.Type().(*StructType); ok && 0 <= i.Index && i.Index < len(t.Fields) {
+		return t.Fields[i.Index]
+	}
+	return Void
+}
+func (i *ExtractValue) Operands() []Value { return []Value{i.X} }
+
+// Cast 类型转换: zext, trunc, sext, bitcast
+type Cast struct {
+	inst
+	Op string
+	X  Value
+	To Type
+}
+
+func (i *Cast) Type() Type        { return i.To }
+func (i *Cast) Operands() []Value { return []Value{i.X} }
+
+// Call 调用函数
+type Call struct {
+	inst
+	Callee *Func
+	Args   []Value
+}
+
+func (i *Call) Type() Type        { return i.Callee.Sig.Ret }
+func (i *Call) Operands() []Value { return append([]Value{i.Callee}, i.Args...) }
+
+// Br 无条件分支
+type Br struct {
+	inst
+	Target *Block
+}
+
+func (i *Br) Type() Type        { return Void }
+func (i *Br) Operands() []Value { return nil }
+func (i *Br) Succs() []*Block   { return []*Block{i.Target} }
+
+// CondBr 条件分支
+type CondBr struct {
+	inst
+	Cond        Value
+	True, False *Block
+}
+
+func (i *CondBr) Type() Type        { return Void }
+func (i *CondBr) Operands() []Value { return []Value{i.Cond} }
+func (i *CondBr) Succs() []*Block   { return []*Block{i.True, i.False} }
+
+// Ret 从函数返回, X 为 nil 时是 ret void
+type Ret struct {
+	inst
+	X Value
+}
+
+func (i *Ret) Type() Type { return Void }
+func (i *Ret) Operands() []Value {
+	if i.X == nil {
+		return nil
+	}
+	return []Value{i.X}
+}
+func (i *Ret) Succs() []*Block { return nil }
+
+// Unreachable 不会执行到的位置
+type Unreachable struct {
+	inst
+}
+
+func (i *Unreachable) Type() Type        { return Void }
+func (i *Unreachable) Operands() []Value { return nil }
+func (i *Unreachable) Succs() []*Block   { return nil }
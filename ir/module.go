@@ -0,0 +1,172 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Value IR 中的值: 常量, 全局变量, 函数, 参数和有结果的指令
+type Value interface {
+	Type() Type
+	Ident() string // 值在 IR 文本中的写法, 例如 %t1, @g, 42, null
+}
+
+// Const 整数常量
+type Const struct {
+	Typ   *IntType
+	Value int64
+}
+
+func NewInt(typ *IntType, value int64) *Const { return &Const{Typ: typ, Value: value} }
+
+func (c *Const) Type() Type    { return c.Typ }
+func (c *Const) Ident() string { return fmt.Sprint(c.Value) }
+
+// Null 空指针常量
+type Null struct {
+	Typ *PointerType
+}
+
+func NewNull(typ *PointerType) *Null { return &Null{Typ: typ} }
+
+func (c *Null) Type() Type    { return c.Typ }
+func (c *Null) Ident() string { return "null" }
+
+// CString 以 NUL 结尾的字符串常量, 类型为 [N x i8]
+type CString struct {
+	Value string
+}
+
+func NewCString(s string) *CString { return &CString{Value: s} }
+
+func (c *CString) Type() Type { return &ArrayType{Len: len(c.Value) + 1, Elem: I8} }
+
+func (c *CString) Ident() string {
+	var sb strings.Builder
+	sb.WriteString(`c"`)
+	for i := 0; i < len(c.Value); i++ {
+		ch := c.Value[i]
+		if ch < ' ' || ch > '~' || ch == '"' || ch == '\\' {
+			fmt.Fprintf(&sb, "\\%02X", ch)
+		} else {
+			sb.WriteByte(ch)
+		}
+	}
+	sb.WriteString(`\00"`)
+	return sb.String()
+}
+
+// Global 全局变量, 值为指向 Content 的指针
+type Global struct {
+	Name     string
+	Content  Type
+	Init     Value
+	Constant bool // 是否为只读的常量
+	Align    int
+}
+
+func (g *Global) Type() Type    { return NewPointer(g.Content) }
+func (g *Global) Ident() string { return "@" + g.Name }
+
+// Param 函数的参数
+type Param struct {
+	Name   string
+	Typ    Type
+	Parent *Func
+}
+
+func NewParam(name string, typ Type) *Param { return &Param{Name: name, Typ: typ} }
+
+func (p *Param) Type() Type    { return p.Typ }
+func (p *Param) Ident() string { return "%" + p.Name }
+
+// Func 函数, 没有基本块时是外部函数的声明
+type Func struct {
+	Name   string
+	Sig    *FuncType
+	Params []*Param
+	Blocks []*Block
+}
+
+func (f *Func) Type() Type    { return NewPointer(f.Sig) }
+func (f *Func) Ident() string { return "@" + f.Name }
+
+// NewBlock 在函数末尾添加基本块, 重名的基本块在输出时加上序号
+func (f *Func) NewBlock(name string) *Block {
+	b := &Block{Name: name, Parent: f}
+	f.Blocks = append(f.Blocks, b)
+	return b
+}
+
+// Block 基本块, 最后一条指令是终结指令
+type Block struct {
+	Name   string
+	Insts  []Instruction
+	Parent *Func
+}
+
+// Ident 返回基本块作为分支目标的写法
+func (b *Block) Ident() string { return "%" + b.Name }
+
+// Term 返回基本块的终结指令, 没有结束的基本块返回 nil
+func (b *Block) Term() Terminator {
+	if len(b.Insts) == 0 {
+		return nil
+	}
+	term, _ := b.Insts[len(b.Insts)-1].(Terminator)
+	return term
+}
+
+// Module 一个 .ll 文件
+type Module struct {
+	Comment string // 输出在文件开头的注释
+	Types   []*StructType
+	Globals []*Global
+	Funcs   []*Func
+}
+
+func NewModule() *Module {
+	return &Module{}
+}
+
+// NewType 定义命名的结构体类型
+func (m *Module) NewType(name string, fields ...Type) *StructType {
+	t := &StructType{Name: name, Fields: fields}
+	m.Types = append(m.Types, t)
+	return t
+}
+
+// NewGlobal 定义初值为 init 的全局变量
+func (m *Module) NewGlobal(name string, init Value) *Global {
+	g := &Global{Name: name, Content: init.Type(), Init: init, Align: 4}
+	m.Globals = append(m.Globals, g)
+	return g
+}
+
+// NewConstant 定义只读的全局常量
+func (m *Module) NewConstant(name string, init Value) *Global {
+	g := m.NewGlobal(name, init)
+	g.Constant = true
+	return g
+}
+
+// NewFunc 声明函数, 添加基本块后成为函数定义
+func (m *Module) NewFunc(name string, ret Type, params ...*Param) *Func {
+	f := &Func{Name: name, Sig: &FuncType{Ret: ret}, Params: params}
+	for _, param := range params {
+		param.Parent = f
+		f.Sig.Params = append(f.Sig.Params, param.Typ)
+	}
+	m.Funcs = append(m.Funcs, f)
+	return f
+}
+
+// Func 按名字查找函数
+func (m *Module) Func(name string) *Func {
+	for _, f := range m.Funcs {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
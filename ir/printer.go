@@ -0,0 +1,187 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String 返回模块的 .ll 文本. 没有名字的值和重名的值在输出前编号.
+func (m *Module) String() string {
+	var sb strings.Builder
+	if m.Comment != "" {
+		fmt.Fprintf(&sb, "; %s\n", m.Comment)
+	}
+	if len(m.Types) != 0 {
+		sb.WriteString("\n")
+		for _, t := range m.Types {
+			fmt.Fprintf(&sb, "%s = type %s\n", t, t.Def())
+		}
+	}
+	if len(m.Globals) != 0 {
+		sb.WriteString("\n")
+		for _, g := range m.Globals {
+			kind := "global"
+			if g.Constant {
+				kind = "constant"
+			}
+			fmt.Fprintf(&sb, "%s = dso_local %s %s %s, align %d\n", g.Ident(), kind, g.Content, g.Init.Ident(), g.Align)
+		}
+	}
+	for _, f := range m.Funcs {
+		sb.WriteString("\n")
+		writeFunc(&sb, f)
+	}
+	return sb.String()
+}
+
+func writeFunc(sb *strings.Builder, f *Func) {
+	if len(f.Blocks) == 0 {
+		params := make([]string, len(f.Params))
+		for i, param := range f.Params {
+			params[i] = param.Typ.String()
+		}
+		fmt.Fprintf(sb, "declare %s %s(%s)\n", f.Sig.Ret, f.Ident(), strings.Join(params, ", "))
+		return
+	}
+
+	f.assignNames()
+	params := make([]string, len(f.Params))
+	for i, param := range f.Params {
+		params[i] = typed(param)
+	}
+	fmt.Fprintf(sb, "define %s %s(%s) {\n", f.Sig.Ret, f.Ident(), strings.Join(params, ", "))
+	for i, b := range f.Blocks {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(sb, "%s:\n", b.Name)
+		for _, inst := range b.Insts {
+			fmt.Fprintf(sb, "\t%s\n", format(inst))
+		}
+	}
+	sb.WriteString("}\n")
+}
+
+// assignNames 给没有名字的参数和指令编号, 并使重名的值和基本块的名字唯一
+func (f *Func) assignNames() {
+	used := make(map[string]bool)
+	next := 0
+	unique := func(name string) string {
+		if name == "" {
+			for used[fmt.Sprintf("t%d", next)] {
+				next++
+			}
+			name = fmt.Sprintf("t%d", next)
+			next++
+		} else if used[name] {
+			n := 1
+			for used[fmt.Sprintf("%s.%d", name, n)] {
+				n++
+			}
+			name = fmt.Sprintf("%s.%d", name, n)
+		}
+		used[name] = true
+		return name
+	}
+
+	// 先保留显式给出的名字, 避免与自动编号冲突
+	for _, param := range f.Params {
+		param.Name = unique(param.Name)
+	}
+	for _, b := range f.Blocks {
+		b.Name = unique(b.Name)
+		for _, inst := range b.Insts {
+			if n := nameOf(inst); n != nil && *n != "" {
+				*n = unique(*n)
+			}
+		}
+	}
+	for _, b := range f.Blocks {
+		for _, inst := range b.Insts {
+			if n := nameOf(inst); n != nil && *n == "" {
+				*n = unique("")
+			}
+		}
+	}
+}
+
+// nameOf 返回有结果的指令的名字, 没有结果的指令返回 nil
+func nameOf(i Instruction) *string {
+	if _, ok := i.Type().(*VoidType); ok {
+		return nil
+	}
+	switch i := i.(type) {
+	case *BinOp:
+		return &i.Name
+	case *ICmp:
+		return &i.Name
+	case *Alloca:
+		return &i.Name
+	case *Load:
+		return &i.Name
+	case *GEP:
+		return &i.Name
+	case *ExtractValue:
+		return &i.Name
+	case *Cast:
+		return &i.Name
+	case *Call:
+		return &i.Name
+	}
+	return nil
+}
+
+// typed 返回带类型的操作数, 例如 i32 %t1
+func typed(v Value) string {
+	return v.Type().String() + " " + v.Ident()
+}
+
+// format 返回指令的文本
+func format(i Instruction) string {
+	var s string
+	switch i := i.(type) {
+	case *BinOp:
+		s = fmt.Sprintf("%s %s, %s", i.Op, typed(i.X), i.Y.Ident())
+	case *ICmp:
+		s = fmt.Sprintf("icmp %s %s, %s", i.Pred, typed(i.X), i.Y.Ident())
+	case *Alloca:
+		s = fmt.Sprintf("alloca %s, align %d", i.Elem, i.Align)
+	case *Load:
+		s = fmt.Sprintf("load %s, %s, align %d", i.Type(), typed(i.Ptr), i.Align)
+	case *Store:
+		return fmt.Sprintf("store %s, %s, align %d", typed(i.Val), typed(i.Ptr), i.Align)
+	case *GEP:
+		indices := make([]string, len(i.Indices))
+		for k, index := range i.Indices {
+			indices[k] = typed(index)
+		}
+		s = fmt.Sprintf("getelementptr %s, %s, %s", i.Elem, typed(i.Ptr), strings.Join(indices, ", "))
+	case *ExtractValue:
+		s = fmt.Sprintf("extractvalue %s, %d", typed(i.X), i.Index)
+	case *Cast:
+		s = fmt.Sprintf("%s %s to %s", i.Op, typed(i.X), i.To)
+	case *Call:
+		args := make([]string, len(i.Args))
+		for k, arg := range i.Args {
+			args[k] = typed(arg)
+		}
+		s = fmt.Sprintf("call %s %s(%s)", i.Callee.Sig.Ret, i.Callee.Ident(), strings.Join(args, ", "))
+	case *Br:
+		return "br label " + i.Target.Ident()
+	case *CondBr:
+		return fmt.Sprintf("br %s, label %s, label %s", typed(i.Cond), i.True.Ident(), i.False.Ident())
+	case *Ret:
+		if i.X == nil {
+			return "ret void"
+		}
+		return "ret " + typed(i.X)
+	case *Unreachable:
+		return "unreachable"
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", i))
+	}
+	if nameOf(i) != nil {
+		return i.Ident() + " = " + s
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+// Package ir 是 LLVM IR 的内存模型, 编译器通过 Builder 生成 IR,
+// 由 Verify 检查后输出为 .ll 文本.
+//
+// 模型只包含编译 pl/0 用到的类型和指令: 整数, 指针, 数组, 结构体和函数类型,
+// 算术, 比较, 内存访问, 类型转换, 调用和分支.
+package ir
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type LLVM 类型
+type Type interface {
+	String() string
+}
+
+// IntType 整数类型 iN
+type IntType struct {
+	Bits int
+}
+
+func (t *IntType) String() string { return fmt.Sprintf("i%d", t.Bits) }
+
+// 常用的整数类型
+var (
+	I1  = &IntType{Bits: 1}
+	I8  = &IntType{Bits: 8}
+	I32 = &IntType{Bits: 32}
+	I64 = &IntType{Bits: 64}
+)
+
+// VoidType 没有值的类型, 用于不返回值的函数和 store 等指令
+type VoidType struct{}
+
+func (t *VoidType) String() string { return "void" }
+
+var Void = &VoidType{}
+
+// PointerType 指向 Elem 的指针
+type PointerType struct {
+	Elem Type
+}
+
+func NewPointer(elem Type) *PointerType { return &PointerType{Elem: elem} }
+
+func (t *PointerType) String() string { return t.Elem.String() + "*" }
+
+// ArrayType 数组类型 [Len x Elem]
+type ArrayType struct {
+	Len  int
+	Elem Type
+}
+
+func (t *ArrayType) String() string { return fmt.Sprintf("[%d x %s]", t.Len, t.Elem) }
+
+// StructType 结构体类型. 命名的结构体由 Module.NewType 创建,
+// 没有名字的是字面结构体, 例如溢出检查内建函数的返回值 { i32, i1 }.
+type StructType struct {
+	Name   string
+	Fields []Type
+}
+
+// NewStruct 返回字面结构体类型
+func NewStruct(fields ...Type) *StructType { return &StructType{Fields: fields} }
+
+func (t *StructType) String() string {
+	if t.Name == "" {
+		return t.Def()
+	}
+	return "%" + t.Name
+}
+
+// Def 返回结构体的定义
+func (t *StructType) Def() string {
+	fields := make([]string, len(t.Fields))
+	for i, field := range t.Fields {
+		fields[i] = field.String()
+	}
+	return "{ " + strings.Join(fields, ", ") + " }"
+}
+
+// FuncType 函数类型
+type FuncType struct {
+	Ret    Type
+	Params []Type
+}
+
+func (t *FuncType) String() string {
+	params := make([]string, len(t.Params))
+	for i, param := range t.Params {
+		params[i] = param.String()
+	}
+	return t.Ret.String() + " (" + strings.Join(params, ", ") + ")"
+}
+
+// Equal 报告两个类型是否相同. 命名的结构体只与自身相同, 字面结构体按字段比较.
+func Equal(t, u Type) bool {
+	switch t := t.(type) {
+	case *IntType:
+		u, ok := u.(*IntType)
+		return ok && t.Bits == u.Bits
+	case *VoidType:
+		_, ok := u.(*VoidType)
+		return ok
+	case *PointerType:
+		u, ok := u.(*PointerType)
+		return ok && Equal(t.Elem, u.Elem)
+	case *ArrayType:
+		u, ok := u.(*ArrayType)
+		return ok && t.Len == u.Len && Equal(t.Elem, u.Elem)
+	case *StructType:
+		u, ok := u.(*StructType)
+		if !ok || t.Name != "" || u.Name != "" || len(t.Fields) != len(u.Fields) {
+			return t == u
+		}
+		for i := range t.Fields {
+			if !Equal(t.Fields[i], u.Fields[i]) {
+				return false
+			}
+		}
+		return true
+	case *FuncType:
+		u, ok := u.(*FuncType)
+		if !ok || !Equal(t.Ret, u.Ret) || len(t.Params) != len(u.Params) {
+			return false
+		}
+		for i := range t.Params {
+			if !Equal(t.Params[i], u.Params[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return t == u
+}
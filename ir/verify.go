@@ -0,0 +1,241 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error 校验发现的一个问题
+type Error struct {
+	Func  string // 所在的函数, 全局变量的问题为空
+	Block string // 所在的基本块
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Func == "":
+		return "ir: " + e.Msg
+	case e.Block == "":
+		return fmt.Sprintf("ir: @%s: %s", e.Func, e.Msg)
+	}
+	return fmt.Sprintf("ir: @%s: %%%s: %s", e.Func, e.Block, e.Msg)
+}
+
+// ErrorList 校验发现的全部问题
+type ErrorList []*Error
+
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// verifier 检查一个模块
+type verifier struct {
+	fn     *Func
+	block  *Block
+	blocks map[*Block]bool // 当前函数的基本块
+	errors ErrorList
+}
+
+func (v *verifier) errorf(format string, args ...interface{}) {
+	e := &Error{Msg: fmt.Sprintf(format, args...)}
+	if v.fn != nil {
+		e.Func = v.fn.Name
+	}
+	if v.block != nil {
+		e.Block = v.block.Name
+	}
+	v.errors = append(v.errors, e)
+}
+
+// Verify 检查模块是否能被 LLVM 接受: 每个基本块以一条终结指令结束,
+// 分支的目标是同一个函数中的基本块, 入口块不是分支的目标,
+// 使用的值在使用之前定义 (定义所在的基本块支配使用处), 指令的操作数类型匹配.
+func Verify(m *Module) error {
+	v := new(verifier)
+	for _, g := range m.Globals {
+		if g.Init == nil || !Equal(g.Init.Type(), g.Content) {
+			v.errorf("global @%s: initializer does not match type %s", g.Name, g.Content)
+		}
+	}
+	for _, f := range m.Funcs {
+		v.verifyFunc(f)
+	}
+	if len(v.errors) != 0 {
+		return v.errors
+	}
+	return nil
+}
+
+func (v *verifier) verifyFunc(f *Func) {
+	v.fn, v.block = f, nil
+	defer func() { v.fn, v.block = nil, nil }()
+	// 先分配名字, 错误信息中才能指出是哪个值
+	f.assignNames()
+	n := len(v.errors)
+
+	v.blocks = make(map[*Block]bool)
+	for _, b := range f.Blocks {
+		v.blocks[b] = true
+	}
+
+	for _, b := range f.Blocks {
+		v.block = b
+		if b.Parent != f {
+			v.errorf("block belongs to another function")
+		}
+		if b.Term() == nil {
+			v.errorf("block does not end with a terminator")
+		}
+		for k, inst := range b.Insts {
+			if _, ok := inst.(Terminator); ok && k != len(b.Insts)-1 {
+				v.errorf("terminator %q in the middle of the block", format(inst))
+			}
+			if inst.Block() != b {
+				v.errorf("%T instruction belongs to another block", inst)
+			}
+			v.verifyInst(inst)
+		}
+	}
+	v.block = nil
+	if len(v.errors) == n {
+		// 控制流图完整时才检查定义和使用的顺序
+		v.verifyDominance(f)
+	}
+}
+
+func (v *verifier) verifyInst(i Instruction) {
+	for _, x := range i.Operands() {
+		if x == nil {
+			v.errorf("%T has a nil operand", i)
+			return
+		}
+		v.verifyOperand(x)
+	}
+
+	switch i := i.(type) {
+	case *BinOp:
+		if !isInt(i.X.Type()) || !Equal(i.X.Type(), i.Y.Type()) {
+			v.errorf("%s operands %s and %s are not the same integer type", i.Op, i.X.Type(), i.Y.Type())
+		}
+	case *ICmp:
+		if !isInt(i.X.Type()) && !isPointer(i.X.Type()) || !Equal(i.X.Type(), i.Y.Type()) {
+			v.errorf("icmp operands %s and %s do not match", i.X.Type(), i.Y.Type())
+		}
+	case *Load:
+		if !isPointer(i.Ptr.Type()) {
+			v.errorf("load from non-pointer %s", typed(i.Ptr))
+		}
+	case *Store:
+		t, ok := i.Ptr.Type().(*PointerType)
+		if !ok {
+			v.errorf("store to non-pointer %s", typed(i.Ptr))
+		} else if !Equal(t.Elem, i.Val.Type()) {
+			v.errorf("store %s to %s", i.Val.Type(), t)
+		}
+	case *GEP:
+		if t, ok := i.Ptr.Type().(*PointerType); !ok || !Equal(t.Elem, i.Elem) {
+			v.errorf("getelementptr base %s is not %s*", i.Ptr.Type(), i.Elem)
+		}
+		if gepType(i.Elem, i.Indices) == nil {
+			v.errorf("getelementptr indices are invalid for %s", i.Elem)
+		}
+		for _, index := range i.Indices {
+			if !isInt(index.Type()) {
+				v.errorf("getelementptr index %s is not an integer", typed(index))
+			}
+		}
+	case *ExtractValue:
+		if t, ok := i.X.Type().(*StructType); !ok || i.Index < 0 || i.Index >= len(t.Fields) {
+			v.errorf("extractvalue index %d is invalid for %s", i.Index, i.X.Type())
+		}
+	case *Cast:
+		v.verifyCast(i)
+	case *Call:
+		sig := i.Callee.Sig
+		if len(i.Args) != len(sig.Params) {
+			v.errorf("call %s with %d arguments, want %d", i.Callee.Ident(), len(i.Args), len(sig.Params))
+			break
+		}
+		for k, arg := range i.Args {
+			if !Equal(arg.Type(), sig.Params[k]) {
+				v.errorf("argument %d of %s is %s, want %s", k, i.Callee.Ident(), arg.Type(), sig.Params[k])
+			}
+		}
+	case *Br:
+		v.verifyTarget(i.Target)
+	case *CondBr:
+		if !Equal(i.Cond.Type(), I1) {
+			v.errorf("branch condition %s is not i1", typed(i.Cond))
+		}
+		v.verifyTarget(i.True)
+		v.verifyTarget(i.False)
+	case *Ret:
+		ret := v.fn.Sig.Ret
+		switch {
+		case i.X == nil && !Equal(ret, Void):
+			v.errorf("ret void in function returning %s", ret)
+		case i.X != nil && !Equal(i.X.Type(), ret):
+			v.errorf("ret %s in function returning %s", i.X.Type(), ret)
+		}
+	}
+}
+
+func (v *verifier) verifyCast(i *Cast) {
+	from, to := i.X.Type(), i.To
+	switch i.Op {
+	case "zext", "sext":
+		if !isInt(from) || !isInt(to) || from.(*IntType).Bits >= to.(*IntType).Bits {
+			v.errorf("%s from %s to %s does not widen an integer", i.Op, from, to)
+		}
+	case "trunc":
+		if !isInt(from) || !isInt(to) || from.(*IntType).Bits <= to.(*IntType).Bits {
+			v.errorf("trunc from %s to %s does not narrow an integer", from, to)
+		}
+	case "bitcast":
+		if !isPointer(from) || !isPointer(to) {
+			v.errorf("bitcast from %s to %s is not between pointers", from, to)
+		}
+	default:
+		v.errorf("unknown cast %s", i.Op)
+	}
+}
+
+// verifyOperand 检查操作数属于当前函数
+func (v *verifier) verifyOperand(x Value) {
+	switch x := x.(type) {
+	case *Param:
+		if x.Parent != v.fn {
+			v.errorf("uses parameter %s of @%s", x.Ident(), x.Parent.Name)
+		}
+	case Instruction:
+		if b := x.Block(); b == nil || b.Parent != v.fn {
+			v.errorf("uses %s which is not defined in this function", x.Ident())
+		}
+	}
+}
+
+func (v *verifier) verifyTarget(b *Block) {
+	switch {
+	case b == nil || b.Parent != v.fn:
+		v.errorf("branch to a block of another function")
+	case !v.blocks[b]:
+		v.errorf("branch to %s which is not in the function", b.Ident())
+	case b == v.fn.Blocks[0]:
+		v.errorf("branch to the entry block")
+	}
+}
+
+func isInt(t Type) bool {
+	_, ok := t.(*IntType)
+	return ok
+}
+
+func isPointer(t Type) bool {
+	_, ok := t.(*PointerType)
+	return ok
+}
@@ -0,0 +1,81 @@
+package ir_test
+
+import (
+	"pl0Compiler/ir"
+	"strings"
+	"testing"
+)
+
+// verify 校验 m, 检查报告的问题中包含 want
+func verify(t *testing.T, m *ir.Module, want string) {
+	t.Helper()
+	err := ir.Verify(m)
+	if err == nil {
+		t.Fatalf("Verify succeeded, want error containing %q\n%s", want, m)
+	}
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("Verify error = %q, want it to contain %q", err, want)
+	}
+}
+
+func TestVerifyDominance(t *testing.T) {
+	m := ir.NewModule()
+	a := ir.NewParam("a", ir.I32)
+	f := m.NewFunc("f", ir.I32, a)
+	entry, then, els, join := f.NewBlock("entry"), f.NewBlock("then"), f.NewBlock("else"), f.NewBlock("join")
+
+	b := ir.NewBuilder()
+	b.SetInsertPoint(entry)
+	b.NewCondBr(b.NewICmp("eq", a, ir.NewInt(ir.I32, 0)), then, els)
+	b.SetInsertPoint(then)
+	x := b.NewAdd(a, ir.NewInt(ir.I32, 1))
+	b.NewBr(join)
+	b.SetInsertPoint(els)
+	b.NewBr(join)
+	b.SetInsertPoint(join)
+	// x 只在 then 分支中定义, 不支配 join
+	b.NewRet(b.NewAdd(x, ir.NewInt(ir.I32, 1)))
+
+	verify(t, m, "does not dominate its use")
+}
+
+func TestVerifyTerminator(t *testing.T) {
+	m := ir.NewModule()
+	f := m.NewFunc("f", ir.Void)
+	entry, next := f.NewBlock("entry"), f.NewBlock("next")
+
+	b := ir.NewBuilder()
+	b.SetInsertPoint(entry)
+	b.NewBr(next)
+	b.SetInsertPoint(next)
+	b.NewAlloca(ir.I32, 4)
+
+	verify(t, m, "block does not end with a terminator")
+}
+
+func TestVerifyCallArity(t *testing.T) {
+	m := ir.NewModule()
+	g := m.NewFunc("g", ir.Void, ir.NewParam("x", ir.I32))
+	f := m.NewFunc("f", ir.Void)
+
+	b := ir.NewBuilder()
+	b.SetInsertPoint(f.NewBlock("entry"))
+	b.NewCall(g)
+	b.NewRet(nil)
+
+	verify(t, m, "call @g with 0 arguments, want 1")
+}
+
+func TestVerifyValid(t *testing.T) {
+	m := ir.NewModule()
+	a := ir.NewParam("a", ir.I32)
+	f := m.NewFunc("f", ir.I32, a)
+
+	b := ir.NewBuilder()
+	b.SetInsertPoint(f.NewBlock("entry"))
+	b.NewRet(b.NewMul(a, a))
+
+	if err := ir.Verify(m); err != nil {
+		t.Fatalf("Verify: %v\n%s", err, m)
+	}
+}
@@ -0,0 +1,97 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"pl0Compiler/token"
+	"sort"
+)
+
+// Error 带位置信息的错误
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e Error) Error() string {
+	if e.Pos.Filename != "" || e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Msg
+	}
+	return e.Msg
+}
+
+// ErrorList 错误列表, 类似 go/scanner.ErrorList
+type ErrorList []*Error
+
+func (p *ErrorList) Add(pos token.Position, msg string) {
+	*p = append(*p, &Error{pos, msg})
+}
+
+func (p *ErrorList) Reset() { *p = (*p)[0:0] }
+
+func (p ErrorList) Len() int      { return len(p) }
+func (p ErrorList) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+
+func (p ErrorList) Less(i, j int) bool {
+	e := &p[i].Pos
+	f := &p[j].Pos
+	if e.Filename != f.Filename {
+		return e.Filename < f.Filename
+	}
+	if e.Line != f.Line {
+		return e.Line < f.Line
+	}
+	if e.Column != f.Column {
+		return e.Column < f.Column
+	}
+	return p[i].Msg < p[j].Msg
+}
+
+// Sort 按文件名, 行号, 列号排序
+func (p ErrorList) Sort() {
+	sort.Sort(p)
+}
+
+// RemoveMultiples 排序并删除同一行中除第一个以外的错误
+func (p *ErrorList) RemoveMultiples() {
+	sort.Sort(p)
+	var last token.Position
+	i := 0
+	for _, e := range *p {
+		if e.Pos.Filename != last.Filename || e.Pos.Line != last.Line {
+			last = e.Pos
+			(*p)[i] = e
+			i++
+		}
+	}
+	*p = (*p)[0:i]
+}
+
+func (p ErrorList) Error() string {
+	switch len(p) {
+	case 0:
+		return "no errors"
+	case 1:
+		return p[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", p[0], len(p)-1)
+}
+
+// Err 没有错误时返回 nil
+func (p ErrorList) Err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return p
+}
+
+// PrintError 逐行打印错误, err 为 ErrorList 时打印全部错误
+func PrintError(w io.Writer, err error) {
+	if list, ok := err.(ErrorList); ok {
+		for _, e := range list {
+			_, _ = fmt.Fprintf(w, "%s\n", e)
+		}
+	} else if err != nil {
+		_, _ = fmt.Fprintf(w, "%s\n", err)
+	}
+}
@@ -2,24 +2,19 @@ package lexer
 
 import (
 	"fmt"
-	gotoken "go/token"
 	"pl0Compiler/token"
 )
 
-func PosString(fileName string, src string, pos int) string {
-	fSet := gotoken.NewFileSet()
-	fSet.AddFile(fileName, 1, len(src)).SetLinesForContent([]byte(src))
-	return fmt.Sprintf("%v", fSet.Position(gotoken.Pos(pos+1)))
-}
-
 type Lexer struct {
+	file     *token.File
 	src      *SourceStream
 	tokens   []token.Token
 	comments []token.Token
 }
 
-func NewLexer(name, input string) *Lexer {
-	p := &Lexer{src: NewSourceStream(name, input)}
+// NewLexer 对 file 中的源代码 input 做词法分析, 记号的位置属于 file
+func NewLexer(file *token.File, input string) *Lexer {
+	p := &Lexer{file: file, src: NewSourceStream(file.Name(), input)}
 	p.run()
 	return p
 }
@@ -40,7 +35,7 @@ func (p *Lexer) emit(typ token.TokenType) {
 	p.tokens = append(p.tokens, token.Token{
 		Type:    typ,
 		Literal: lit,
-		Pos:     token.Pos(pos + 1),
+		Pos:     p.file.Pos(pos),
 	})
 }
 
@@ -49,15 +44,16 @@ func (p *Lexer) emitComment() {
 	p.comments = append(p.comments, token.Token{
 		Type:    token.COMMENT,
 		Literal: lit,
-		Pos:     token.Pos(pos + 1),
+		Pos:     p.file.Pos(pos),
 	})
 }
 
 func (p *Lexer) errorf(format string, args ...interface{}) {
+	// 错误位于当前记号的开始处, 如注释或字符串的开始定界符
 	tok := token.Token{
 		Type:    token.ERROR,
 		Literal: fmt.Sprintf(format, args...),
-		Pos:     token.Pos(p.src.pos),
+		Pos:     p.file.Pos(p.src.start),
 	}
 	p.tokens = append(p.tokens, tok)
 	panic(tok)
@@ -105,26 +101,22 @@ func (p *Lexer) run() (tokens []token.Token) {
 			p.emit(token.SUB)
 		case r == '*': // *
 			p.emit(token.MUL)
-		case r == '/': // /
-			p.emit(token.DIV)
-			//peek := p.src.Peek()
-			//if peek == '/' {
-			//	// line comment
-			//	for {
-			//		t := p.src.Read()
-			//		if t == '\n' {
-			//			p.src.Unread()
-			//			p.emitComment()
-			//			break
-			//		}
-			//		if t == rune(token.EOF) {
-			//			p.emitComment()
-			//			return
-			//		}
-			//	}
-			//} else {
-			//	p.emit(token.DIV)
-			//}
+		case r == '/': // / 或 // 行注释
+			if p.src.Peek() != '/' {
+				p.emit(token.DIV)
+				break
+			}
+			for {
+				if t := p.src.Read(); t == '\n' {
+					p.src.Unread()
+					break
+				} else if t == rune(token.EOF) {
+					break
+				}
+			}
+			p.emitComment()
+		case r == '%': // %
+			p.emit(token.MOD)
 		case r == '=': // =
 			p.emit(token.EQL)
 		case r == '<': // < <= <>
@@ -145,56 +137,57 @@ func (p *Lexer) run() (tokens []token.Token) {
 				p.src.Unread()
 				p.emit(token.GTR)
 			}
-		case r == ':':
-			p.src.Read()
+		case r == ':': // :=
+			if p.src.Read() != '=' {
+				p.src.Unread()
+				p.errorf("unknown character %q, expect \":=\"", r)
+			}
 			p.emit(token.ASSIGN)
 		case r == '.':
 			p.emit(token.PERIOD)
 		case r == ',':
 			p.emit(token.COMMA)
-		case r == '(': // (
+		case r == '(': // ( 或 (* 注释 *)
+			if p.src.Peek() == '*' {
+				p.src.Read()
+				p.lexBlockComment("*)")
+				break
+			}
 			p.emit(token.LPAREN)
-			//peek := p.src.Read()
-			//if peek == '*' {
-			//	// multiline comment
-			//	for {
-			//		t := p.src.Read()
-			//		if t == '*' && p.src.Read() == ')' {
-			//			p.src.Read()
-			//			p.emitComment()
-			//			break
-			//		}
-			//		if t == rune(token.EOF) {
-			//			p.errorf("unterminated quoted string")
-			//			return
-			//		}
-			//	}
-			//} else {
-			//	p.emit(token.LPAREN)
-			//}
 		case r == ')':
 			p.emit(token.RPAREN)
-		case r == '{':
-			p.src.IgnoreToken()
+		case r == '"': // "path", 不支持转义
 			for {
 				t := p.src.Read()
-				if t == '}' {
-					p.src.Unread()
-					p.emitComment()
-					p.src.IgnoreToken()
+				if t == '"' {
+					p.emit(token.STRING)
 					break
 				}
 				if t == rune(token.EOF) || t == '\n' {
-					p.errorf("unterminated quoted string")
+					p.errorf("unterminated string literal")
 					return
 				}
 			}
+		case r == '{': // { 注释 }
+			p.lexBlockComment("}")
+		default:
+			p.errorf("unknown character %q", r)
+		}
+	}
+}
+
+// lexBlockComment 读取到 closer 为止的注释, 注释可以跨行, 字面值包含定界符
+func (p *Lexer) lexBlockComment(closer string) {
+	for !p.src.AcceptString(closer) {
+		if p.src.Read() == rune(token.EOF) {
+			p.errorf("comment not terminated")
 		}
 	}
+	p.emitComment()
 }
 
-func Lex(name, input string) (tokens, comments []token.Token) {
-	l := NewLexer(name, input)
+func Lex(file *token.File, input string) (tokens, comments []token.Token) {
+	l := NewLexer(file, input)
 	tokens = l.Tokens()
 	comments = l.Comments()
 	return
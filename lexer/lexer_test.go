@@ -0,0 +1,133 @@
+package lexer_test
+
+import (
+	"fmt"
+	"pl0Compiler/lexer"
+	"pl0Compiler/token"
+	"reflect"
+	"testing"
+)
+
+// lex 返回 src 的记号和注释, 格式为 "line:col literal", 文件结束为 "line:col EOF",
+// 词法错误为 "line:col error: msg"
+func lex(src string) (tokens, comments []string) {
+	fset := token.NewFileSet()
+	toks, comms := lexer.Lex(fset.AddFile("test.pl", src), src)
+	format := func(tok token.Token) string {
+		pos := fset.Position(tok.Pos)
+		switch tok.Type {
+		case token.EOF:
+			return fmt.Sprintf("%d:%d EOF", pos.Line, pos.Column)
+		case token.ERROR:
+			return fmt.Sprintf("%d:%d error: %s", pos.Line, pos.Column, tok.Literal)
+		}
+		return fmt.Sprintf("%d:%d %s", pos.Line, pos.Column, tok.Literal)
+	}
+	for _, tok := range toks {
+		tokens = append(tokens, format(tok))
+	}
+	for _, tok := range comms {
+		if tok.Type != token.COMMENT {
+			panic(fmt.Sprintf("comment %v has type %v", tok, tok.Type))
+		}
+		comments = append(comments, format(tok))
+	}
+	return
+}
+
+func TestComments(t *testing.T) {
+	tests := []struct {
+		src      string
+		tokens   []string
+		comments []string
+	}{
+		{
+			"x := 1; // 行注释\ny",
+			[]string{"1:1 x", "1:3 :=", "1:6 1", "1:7 ;", "2:1 y", "2:2 EOF"},
+			[]string{"1:9 // 行注释"},
+		},
+		{
+			// 单独的 '/' 是除号
+			"a / b //c",
+			[]string{"1:1 a", "1:3 /", "1:5 b", "1:10 EOF"},
+			[]string{"1:7 //c"},
+		},
+		{
+			"(* 跨行\n   的注释 *) x (**) (y)",
+			[]string{"2:11 x", "2:18 (", "2:19 y", "2:20 )", "2:21 EOF"},
+			[]string{"1:1 (* 跨行\n   的注释 *)", "2:13 (**)"},
+		},
+		{
+			// (* *) 不能嵌套, 第一个 *) 结束注释
+			"(* a (* b *) c *)",
+			[]string{"1:14 c", "1:16 *", "1:17 )", "1:18 EOF"},
+			[]string{"1:1 (* a (* b *)"},
+		},
+		{
+			"{ 第一行\n  第二行 }x{}",
+			[]string{"2:8 x", "2:11 EOF"},
+			[]string{"1:1 { 第一行\n  第二行 }", "2:9 {}"},
+		},
+		{
+			// 不同的注释互相包含
+			"{ (* // } (* { } *)",
+			[]string{"1:20 EOF"},
+			[]string{"1:1 { (* // }", "1:11 (* { } *)"},
+		},
+		{
+			// 文件末尾的注释, 没有换行
+			"end. // 结束",
+			[]string{"1:1 end", "1:4 .", "1:11 EOF"},
+			[]string{"1:6 // 结束"},
+		},
+		{
+			"end.\n{ 结束 }",
+			[]string{"1:1 end", "1:4 .", "2:7 EOF"},
+			[]string{"2:1 { 结束 }"},
+		},
+		{
+			"end.\n(* 结束 *)\n",
+			[]string{"1:1 end", "1:4 .", "3:1 EOF"},
+			[]string{"2:1 (* 结束 *)"},
+		},
+		{
+			"//",
+			[]string{"1:3 EOF"},
+			[]string{"1:1 //"},
+		},
+	}
+	for _, tt := range tests {
+		tokens, comments := lex(tt.src)
+		if !reflect.DeepEqual(tokens, tt.tokens) {
+			t.Errorf("%q: tokens\ngot  %q\nwant %q", tt.src, tokens, tt.tokens)
+		}
+		if !reflect.DeepEqual(comments, tt.comments) {
+			t.Errorf("%q: comments\ngot  %q\nwant %q", tt.src, comments, tt.comments)
+		}
+	}
+}
+
+// TestErrors 检查词法错误位于出错记号的开始处, 并且是最后一个记号
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"x := 1;\n(* 没有结束\n x := 2;", "2:1 error: comment not terminated"},
+		{"x := 1; (* 只有 *", "1:9 error: comment not terminated"},
+		{"x := 1;\n  { 没有结束\n\n", "2:3 error: comment not terminated"},
+		{"{ (* *)", "1:1 error: comment not terminated"},
+		{"import \"lib.pl\nx", "1:8 error: unterminated string literal"},
+		{"x := 1 $ 2;", "1:8 error: unknown character '$'"},
+		{"中文 := #;", "1:7 error: unknown character '#'"},
+		{"x := 1;\n  €", "2:3 error: unknown character '€'"},
+		{"x : 1;", "1:3 error: unknown character ':', expect \":=\""},
+		{"x :", "1:3 error: unknown character ':', expect \":=\""},
+	}
+	for _, tt := range tests {
+		tokens, _ := lex(tt.src)
+		if got := tokens[len(tokens)-1]; got != tt.want {
+			t.Errorf("%q: last token = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
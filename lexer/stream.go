@@ -50,6 +50,16 @@ func (p *SourceStream) AcceptRun(valid string) (ok bool) {
 	return
 }
 
+// AcceptString 如果接下来的输入为 s 则读取 s
+func (p *SourceStream) AcceptString(s string) bool {
+	if strings.HasPrefix(p.input[p.pos:], s) {
+		p.pos += len(s)
+		p.width = 0
+		return true
+	}
+	return false
+}
+
 func (p *SourceStream) EmitToken() (lit string, pos int) {
 	lit, pos = p.input[p.start:p.pos], p.start
 	p.start = p.pos
@@ -0,0 +1,127 @@
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+)
+
+// Client 进程内的 JSON-RPC 客户端, 用于测试和驱动 Server.
+//
+// 客户端在单独的 goroutine 中读取消息, 服务器的通知按顺序保存,
+// 由 WaitNotification 取出, 因此服务器发送通知时不会阻塞.
+type Client struct {
+	conn *Conn
+
+	mu            sync.Mutex
+	cond          *sync.Cond
+	nextID        int
+	pending       map[string]chan *Message // 等待响应的请求, 以 id 为键
+	notifications []*Message
+	err           error // 读取消息的错误, 之后不会再有新消息
+}
+
+// NewClient 创建客户端, 从 in 读取服务器的消息, 向 out 发送请求
+func NewClient(in io.Reader, out io.Writer) *Client {
+	c := &Client{
+		conn:    NewConn(in, out),
+		pending: make(map[string]chan *Message),
+	}
+	c.cond = sync.NewCond(&c.mu)
+	go c.readLoop()
+	return c
+}
+
+func (c *Client) readLoop() {
+	for {
+		msg, err := c.conn.Read()
+		c.mu.Lock()
+		if err != nil {
+			c.err = err
+			for id, ch := range c.pending {
+				close(ch)
+				delete(c.pending, id)
+			}
+			c.cond.Broadcast()
+			c.mu.Unlock()
+			return
+		}
+		if msg.Method == "" && msg.ID != nil {
+			if ch, ok := c.pending[string(*msg.ID)]; ok {
+				delete(c.pending, string(*msg.ID))
+				ch <- msg
+			}
+		} else {
+			c.notifications = append(c.notifications, msg)
+			c.cond.Broadcast()
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Call 发送请求并等待响应, 结果解码到 result 中, result 可以为 nil
+func (c *Client) Call(method string, params, result interface{}) error {
+	raw, err := rawJSON(params)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	ch := make(chan *Message, 1)
+	c.pending[string(id)] = ch
+	c.mu.Unlock()
+
+	if err := c.conn.Write(&Message{ID: &id, Method: method, Params: raw}); err != nil {
+		return err
+	}
+	resp, ok := <-ch
+	if !ok {
+		return errors.New("jsonrpc: connection closed")
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+// Notify 发送通知
+func (c *Client) Notify(method string, params interface{}) error {
+	raw, err := rawJSON(params)
+	if err != nil {
+		return err
+	}
+	return c.conn.Write(&Message{Method: method, Params: raw})
+}
+
+// WaitNotification 等待并取出下一条名为 method 的通知, 参数解码到 params 中.
+// 连接关闭后返回读取消息的错误.
+func (c *Client) WaitNotification(method string, params interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		for i, msg := range c.notifications {
+			if msg.Method == method {
+				c.notifications = append(c.notifications[:i], c.notifications[i+1:]...)
+				if params == nil {
+					return nil
+				}
+				return json.Unmarshal(msg.Params, params)
+			}
+		}
+		if c.err != nil {
+			return c.err
+		}
+		c.cond.Wait()
+	}
+}
@@ -0,0 +1,286 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"pl0Compiler/ast"
+	"pl0Compiler/build"
+	"pl0Compiler/check"
+	"pl0Compiler/compiler"
+	"pl0Compiler/lexer"
+	"pl0Compiler/token"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// document 一个打开的文档及其分析结果
+type document struct {
+	uri     string
+	path    string // 文档对应的文件名, 用于解析导入路径
+	version int
+	text    string
+
+	err     error        // 解析和检查的错误
+	program *ast.Program // 解析成功时的语法树, 包括导入的模块
+	info    *check.Info
+
+	sources map[string]string                  // 文件名对应的源代码, 包括导入的模块
+	refs    []ref                              // 标识符的声明和引用, 按位置排序
+	decls   map[*compiler.Object]ast.Node      // 对象所在的声明, 用于查找注释
+	owners  map[*compiler.Object]*ast.ProcDecl // 参数所属的过程
+}
+
+// ref 标识符在源代码中的一次出现
+type ref struct {
+	pos, end token.Pos
+	obj      *compiler.Object
+	decl     bool // 是否为声明处
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{uri: uri, path: uriToPath(uri), version: version, text: text}
+	d.analyze()
+	return d
+}
+
+// analyze 解析并检查文档. 检查有错误时保留语法树, 仍然可以跳转和查找引用.
+func (d *document) analyze() {
+	ctx := build.NewContext(nil)
+	program, err := ctx.ParseFiles([]string{d.path}, []interface{}{d.text})
+	if err != nil {
+		d.err = err
+		return
+	}
+	info := new(check.Info)
+	d.err = check.Check(program, info)
+	d.program, d.info = program, info
+
+	d.sources = make(map[string]string)
+	d.decls = make(map[*compiler.Object]ast.Node)
+	d.owners = make(map[*compiler.Object]*ast.ProcDecl)
+	visited := make(map[*ast.Program]bool)
+	d.index(program, visited)
+
+	for ident, obj := range info.Defs {
+		d.refs = append(d.refs, ref{ident.Pos(), ident.End(), obj, true})
+	}
+	for ident, obj := range info.Uses {
+		d.refs = append(d.refs, ref{ident.Pos(), ident.End(), obj, false})
+	}
+	sort.Slice(d.refs, func(i, j int) bool { return d.refs[i].pos < d.refs[j].pos })
+}
+
+// index 记录程序及其导入的模块中的源代码, 声明和过程名字
+func (d *document) index(program *ast.Program, visited map[*ast.Program]bool) {
+	if visited[program] {
+		return
+	}
+	visited[program] = true
+	for _, imp := range program.Imports {
+		if imp.Module != nil {
+			d.index(imp.Module, visited)
+		}
+	}
+	d.sources[program.FileName] = program.Source
+
+	ast.Inspect(program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.VarDecl:
+			for _, name := range n.Names {
+				if obj := d.info.Defs[name]; obj != nil {
+					d.decls[obj] = n
+				}
+			}
+		case *ast.ConstDecl:
+			for _, def := range n.Definition {
+				if obj := d.info.Defs[def.Target]; obj != nil {
+					d.decls[obj] = n
+				}
+			}
+		case *ast.ProcDecl:
+			for _, field := range n.Params.List {
+				if obj := d.info.Defs[field.Name]; obj != nil {
+					d.owners[obj] = n
+				}
+			}
+		}
+		return true
+	})
+	d.indexProcs(d.info.Scopes[program], program.Funcs)
+}
+
+// indexProcs 过程的名字不是 *ast.Ident, 在声明过程的作用域中查找其对象
+func (d *document) indexProcs(scope *compiler.Scope, funcs []*ast.ProcDecl) {
+	if scope == nil {
+		return
+	}
+	for _, fn := range funcs {
+		if obj := scope.Objects[fn.Name]; obj != nil && obj.Node == ast.Node(fn) {
+			d.decls[obj] = fn
+			d.refs = append(d.refs, ref{fn.NamePos, fn.NamePos + token.Pos(len(fn.Name)), obj, true})
+		}
+		d.indexProcs(d.info.Scopes[fn], fn.Funcs)
+	}
+}
+
+// file 返回文档自己的文件
+func (d *document) file() *token.File {
+	for _, f := range d.program.FileSet.Files() {
+		if f.Name() == d.path {
+			return f
+		}
+	}
+	return nil
+}
+
+// refAt 返回文档中 pos 处的标识符, 光标在标识符末尾时也算
+func (d *document) refAt(pos Position) *ref {
+	if d.program == nil {
+		return nil
+	}
+	f := d.file()
+	if f == nil {
+		return nil
+	}
+	p := f.Pos(offsetOf(d.text, pos))
+	i := sort.Search(len(d.refs), func(i int) bool { return d.refs[i].end >= p })
+	if i < len(d.refs) && d.refs[i].pos <= p {
+		return &d.refs[i]
+	}
+	return nil
+}
+
+// declOf 返回对象声明处的标识符
+func (d *document) declOf(obj *compiler.Object) *ref {
+	for i := range d.refs {
+		if d.refs[i].obj == obj && d.refs[i].decl {
+			return &d.refs[i]
+		}
+	}
+	return nil
+}
+
+// location 把语法树中的位置范围转为 LSP 的位置, 位置可以在导入的模块中
+func (d *document) location(pos, end token.Pos) (Location, bool) {
+	f := d.program.FileSet.File(pos)
+	if f == nil {
+		return Location{}, false
+	}
+	src, ok := d.sources[f.Name()]
+	if !ok {
+		return Location{}, false
+	}
+	uri := d.uri
+	if f.Name() != d.path {
+		uri = pathToURI(f.Name())
+	}
+	return Location{
+		URI: uri,
+		Range: Range{
+			Start: positionOf(src, f.Offset(pos)),
+			End:   positionOf(src, f.Offset(end)),
+		},
+	}, true
+}
+
+// rangeOf 返回文档中 pos 到 end 的范围
+func (d *document) rangeOf(pos, end token.Pos) Range {
+	loc, _ := d.location(pos, end)
+	return loc.Range
+}
+
+// diagnostics 把解析和检查的错误转为诊断. 其它文件中的错误放在文档开头.
+func (d *document) diagnostics() []Diagnostic {
+	list := []Diagnostic{}
+	if d.err == nil {
+		return list
+	}
+	errs, ok := d.err.(lexer.ErrorList)
+	if !ok {
+		return append(list, Diagnostic{Severity: SeverityError, Source: "pl0", Message: d.err.Error()})
+	}
+	for _, e := range errs {
+		diag := Diagnostic{Severity: SeverityError, Source: "pl0", Message: e.Msg}
+		if e.Pos.Filename == d.path && e.Pos.IsValid() {
+			start := e.Pos.Offset
+			diag.Range = Range{Start: positionOf(d.text, start), End: positionOf(d.text, wordEnd(d.text, start))}
+		} else {
+			diag.Message = e.Error()
+		}
+		list = append(list, diag)
+	}
+	return list
+}
+
+// wordEnd 返回从 offset 开始的单词的结束位置, 不是单词时包含一个字符
+func wordEnd(text string, offset int) int {
+	end := offset
+	for end < len(text) && isWordByte(text[end]) {
+		end++
+	}
+	if end == offset && end < len(text) && text[end] != '\n' {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	return end
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// positionOf 把字节偏移转为 LSP 的位置
+func positionOf(text string, offset int) Position {
+	var pos Position
+	for _, r := range text[:offset] {
+		if r == '\n' {
+			pos.Line++
+			pos.Character = 0
+		} else {
+			pos.Character += utf16Len(r)
+		}
+	}
+	return pos
+}
+
+// offsetOf 把 LSP 的位置转为字节偏移, 超出行尾时取行尾
+func offsetOf(text string, pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	for col := 0; col < pos.Character && offset < len(text) && text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		col += utf16Len(r)
+		offset += size
+	}
+	return offset
+}
+
+// utf16Len 返回 r 的 UTF-16 编码单元数
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -0,0 +1,398 @@
+// Package lsp 实现 pl/0 的语言服务器, 通过 stdin/stdout 以 LSP 协议与编辑器通信.
+//
+// 服务器提供诊断, 跳转到定义, 查找引用, 悬停提示, 文档符号和格式化.
+// NewServer 可以使用任意的 io.Reader 和 io.Writer, 配合 Client 在进程内测试.
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"pl0Compiler/ast"
+	"pl0Compiler/compiler"
+	"pl0Compiler/format"
+	"pl0Compiler/token"
+	"strings"
+)
+
+// Server 语言服务器, 按顺序处理客户端发来的消息
+type Server struct {
+	conn     *Conn
+	docs     map[string]*document // 打开的文档, 以 uri 为键
+	shutdown bool
+}
+
+// handler 处理一个请求或通知, 通知的返回值被忽略
+type handler func(s *Server, params json.RawMessage) (interface{}, error)
+
+var handlers = map[string]handler{
+	"initialize":      (*Server).initialize,
+	"initialized":     ignore,
+	"shutdown":        (*Server).shutdownRequest,
+	"$/cancelRequest": ignore,
+
+	"textDocument/didOpen":   (*Server).didOpen,
+	"textDocument/didChange": (*Server).didChange,
+	"textDocument/didClose":  (*Server).didClose,
+	"textDocument/didSave":   ignore,
+
+	"textDocument/definition":     (*Server).definition,
+	"textDocument/references":     (*Server).references,
+	"textDocument/hover":          (*Server).hover,
+	"textDocument/documentSymbol": (*Server).documentSymbol,
+	"textDocument/formatting":     (*Server).formatting,
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		conn: NewConn(in, out),
+		docs: make(map[string]*document),
+	}
+}
+
+// Run 处理消息直到收到 exit 通知或输入结束
+func (s *Server) Run() error {
+	for {
+		msg, err := s.conn.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if e, ok := err.(*ResponseError); ok {
+				// 无法解析的消息没有 id, 按规范以 null id 响应
+				if err := s.conn.Write(&Message{ID: nullID(), Error: e}); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// handle 处理一条消息, 请求的结果写回客户端
+func (s *Server) handle(msg *Message) error {
+	if msg.Method == "" {
+		// 服务器不发送请求, 忽略客户端的响应
+		return nil
+	}
+	result, err := s.dispatch(msg)
+	if msg.ID == nil {
+		return nil
+	}
+
+	resp := &Message{ID: msg.ID}
+	if err == nil {
+		resp.Result, err = rawJSON(result)
+	}
+	if err != nil {
+		e, ok := err.(*ResponseError)
+		if !ok {
+			e = &ResponseError{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Result, resp.Error = nil, e
+	}
+	return s.conn.Write(resp)
+}
+
+func (s *Server) dispatch(msg *Message) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &ResponseError{Code: CodeInternalError, Message: fmt.Sprint(r)}
+		}
+	}()
+
+	h, ok := handlers[msg.Method]
+	if !ok {
+		return nil, &ResponseError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	if s.shutdown && msg.ID != nil {
+		return nil, &ResponseError{Code: CodeInvalidRequest, Message: "server is shutting down"}
+	}
+	return h(s, msg.Params)
+}
+
+func ignore(s *Server, params json.RawMessage) (interface{}, error) {
+	return nil, nil
+}
+
+// decode 解析请求的参数
+func decode(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &ResponseError{Code: CodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func nullID() *json.RawMessage {
+	id := json.RawMessage("null")
+	return &id
+}
+
+// notify 向客户端发送通知
+func (s *Server) notify(method string, params interface{}) error {
+	raw, err := rawJSON(params)
+	if err != nil {
+		return err
+	}
+	return s.conn.Write(&Message{Method: method, Params: raw})
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           TextDocumentSyncKindFull,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			ReferencesProvider:         true,
+			DocumentSymbolProvider:     true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: ServerInfo{Name: "pl0"},
+	}, nil
+}
+
+func (s *Server) shutdownRequest(params json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) (interface{}, error) {
+	var p DidOpenTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	doc := newDocument(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text)
+	s.docs[doc.uri] = doc
+	return nil, s.publish(doc)
+}
+
+func (s *Server) didChange(params json.RawMessage) (interface{}, error) {
+	var p DidChangeTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	if len(p.ContentChanges) == 0 {
+		return nil, nil
+	}
+	text := p.ContentChanges[len(p.ContentChanges)-1].Text
+	doc := newDocument(p.TextDocument.URI, p.TextDocument.Version, text)
+	s.docs[doc.uri] = doc
+	return nil, s.publish(doc)
+}
+
+func (s *Server) didClose(params json.RawMessage) (interface{}, error) {
+	var p DidCloseTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	delete(s.docs, p.TextDocument.URI)
+	// 关闭的文档不再显示诊断
+	return nil, s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []Diagnostic{},
+	})
+}
+
+// publish 发送文档的诊断
+func (s *Server) publish(doc *document) error {
+	return s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: doc.diagnostics(),
+	})
+}
+
+// document 返回已打开的文档
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &ResponseError{Code: CodeInvalidParams, Message: "document not open: " + uri}
+	}
+	return doc, nil
+}
+
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	r := doc.refAt(p.Position)
+	if r == nil {
+		return nil, nil
+	}
+	decl := doc.declOf(r.obj)
+	if decl == nil {
+		// 内置的过程没有声明
+		return nil, nil
+	}
+	loc, ok := doc.location(decl.pos, decl.end)
+	if !ok {
+		return nil, nil
+	}
+	return &loc, nil
+}
+
+func (s *Server) references(params json.RawMessage) (interface{}, error) {
+	var p ReferenceParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	locs := []Location{}
+	r := doc.refAt(p.Position)
+	if r == nil {
+		return locs, nil
+	}
+	for _, x := range doc.refs {
+		if x.obj != r.obj || x.decl && !p.Context.IncludeDeclaration {
+			continue
+		}
+		if loc, ok := doc.location(x.pos, x.end); ok {
+			locs = append(locs, loc)
+		}
+	}
+	return locs, nil
+}
+
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	var p TextDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	r := doc.refAt(p.Position)
+	if r == nil {
+		return nil, nil
+	}
+	rng := doc.rangeOf(r.pos, r.end)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: doc.describe(r.obj)},
+		Range:    &rng,
+	}, nil
+}
+
+// describe 返回对象的说明: 对象的种类和声明, 以及声明的注释
+func (d *document) describe(obj *compiler.Object) string {
+	var sig string
+	switch obj.Kind {
+	case compiler.Con:
+		sig = fmt.Sprintf("const %s = %d", obj.Name, obj.Value)
+	case compiler.Var:
+		sig = "var " + obj.Name
+	case compiler.Param:
+		sig = "param " + obj.Name
+		if fn := d.owners[obj]; fn != nil {
+			sig += " // parameter of " + fn.Name
+		}
+	case compiler.Proc:
+		if fn, ok := obj.Node.(*ast.ProcDecl); ok {
+			sig = "procedure " + fn.Name + paramList(fn)
+		} else {
+			sig = "procedure " + obj.Name + " // builtin"
+		}
+	default:
+		sig = obj.Kind.String() + " " + obj.Name
+	}
+
+	text := "```pl0\n" + sig + "\n```"
+	var doc, comment *ast.CommentGroup
+	switch decl := d.decls[obj].(type) {
+	case *ast.VarDecl:
+		doc, comment = decl.Doc, decl.Comment
+	case *ast.ConstDecl:
+		doc, comment = decl.Doc, decl.Comment
+	case *ast.ProcDecl:
+		doc, comment = decl.Doc, decl.Comment
+	}
+	if doc == nil {
+		doc = comment
+	}
+	if s := doc.Text(); s != "" {
+		text += "\n\n" + s
+	}
+	return text
+}
+
+// paramList 返回过程的参数列表, 没有参数时为空字符串
+func paramList(fn *ast.ProcDecl) string {
+	if fn.Params == nil || len(fn.Params.List) == 0 {
+		return ""
+	}
+	names := make([]string, len(fn.Params.List))
+	for i, field := range fn.Params.List {
+		names[i] = field.Name.Name
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+func (s *Server) documentSymbol(params json.RawMessage) (interface{}, error) {
+	var p DocumentSymbolParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if doc.program == nil {
+		return []DocumentSymbol{}, nil
+	}
+	return doc.symbols(doc.program.Funcs), nil
+}
+
+// symbols 返回过程的符号, 嵌套的过程作为子符号
+func (d *document) symbols(funcs []*ast.ProcDecl) []DocumentSymbol {
+	list := []DocumentSymbol{}
+	for _, fn := range funcs {
+		list = append(list, DocumentSymbol{
+			Name:           fn.Name,
+			Detail:         "procedure" + paramList(fn),
+			Kind:           SymbolKindFunction,
+			Range:          d.rangeOf(fn.Pos(), fn.End()),
+			SelectionRange: d.rangeOf(fn.NamePos, fn.NamePos+token.Pos(len(fn.Name))),
+			Children:       d.symbols(fn.Funcs),
+		})
+	}
+	return list
+}
+
+func (s *Server) formatting(params json.RawMessage) (interface{}, error) {
+	var p DocumentFormattingParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	out, err := format.Source(doc.path, []byte(doc.text))
+	if err != nil {
+		// 有语法错误时不格式化, 错误已经通过诊断报告
+		return nil, nil
+	}
+	edits := []TextEdit{}
+	if string(out) != doc.text {
+		edits = append(edits, TextEdit{
+			Range:   Range{End: positionOf(doc.text, len(doc.text))},
+			NewText: string(out),
+		})
+	}
+	return edits, nil
+}
@@ -0,0 +1,259 @@
+package lsp_test
+
+import (
+	"io"
+	"pl0Compiler/format"
+	"pl0Compiler/lsp"
+	"strings"
+	"testing"
+)
+
+const testURI = "file:///tmp/lsp/test.pl"
+
+// testSrc 是测试用的文档, 缩进不是格式化后的样子
+const testSrc = `// 累加的结果
+var total;
+const step = 2;
+procedure add(n);
+    procedure twice;
+    begin
+        total := total + n + n;
+    end;
+begin
+  total := total + n;
+end;
+begin
+  total := 0;
+  call add(step);
+  read total;
+end.
+`
+
+// startServer 通过管道连接服务器和客户端, 测试结束时关闭服务器
+func startServer(t *testing.T) *lsp.Client {
+	t.Helper()
+	sr, cw := io.Pipe()
+	cr, sw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- lsp.NewServer(sr, sw).Run()
+		sw.Close()
+	}()
+	c := lsp.NewClient(cr, cw)
+	t.Cleanup(func() {
+		if err := c.Call("shutdown", nil, nil); err != nil {
+			t.Errorf("shutdown: %v", err)
+		}
+		if err := c.Notify("exit", nil); err != nil {
+			t.Errorf("exit: %v", err)
+		}
+		if err := <-done; err != nil {
+			t.Errorf("server: %v", err)
+		}
+		cw.Close()
+	})
+	return c
+}
+
+// open 打开文档并返回服务器发布的诊断
+func open(t *testing.T, c *lsp.Client, uri, text string) lsp.PublishDiagnosticsParams {
+	t.Helper()
+	err := c.Notify("textDocument/didOpen", &lsp.DidOpenTextDocumentParams{
+		TextDocument: lsp.TextDocumentItem{URI: uri, LanguageID: "pl0", Version: 1, Text: text},
+	})
+	if err != nil {
+		t.Fatalf("didOpen: %v", err)
+	}
+	var diags lsp.PublishDiagnosticsParams
+	if err := c.WaitNotification("textDocument/publishDiagnostics", &diags); err != nil {
+		t.Fatalf("publishDiagnostics: %v", err)
+	}
+	if diags.URI != uri {
+		t.Fatalf("publishDiagnostics uri = %q, want %q", diags.URI, uri)
+	}
+	return diags
+}
+
+func at(line, char int) lsp.TextDocumentPositionParams {
+	return lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{URI: testURI},
+		Position:     lsp.Position{Line: line, Character: char},
+	}
+}
+
+func rng(line, start, end int) lsp.Range {
+	return lsp.Range{Start: lsp.Position{Line: line, Character: start}, End: lsp.Position{Line: line, Character: end}}
+}
+
+func TestInitialize(t *testing.T) {
+	c := startServer(t)
+	var res lsp.InitializeResult
+	if err := c.Call("initialize", struct{}{}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.ServerInfo.Name != "pl0" {
+		t.Errorf("server name = %q, want %q", res.ServerInfo.Name, "pl0")
+	}
+	caps := res.Capabilities
+	if !caps.HoverProvider || !caps.DefinitionProvider || !caps.ReferencesProvider ||
+		!caps.DocumentSymbolProvider || !caps.DocumentFormattingProvider {
+		t.Errorf("capabilities = %+v, want all providers", caps)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := startServer(t)
+	if diags := open(t, c, testURI, testSrc); len(diags.Diagnostics) != 0 {
+		t.Errorf("diagnostics = %+v, want none", diags.Diagnostics)
+	}
+
+	diags := open(t, c, "file:///tmp/lsp/bad.pl", "var x;\nbegin\n  y := x;\nend.\n")
+	if len(diags.Diagnostics) != 1 {
+		t.Fatalf("diagnostics = %+v, want one", diags.Diagnostics)
+	}
+	d := diags.Diagnostics[0]
+	if d.Message != "undefined: y" || d.Severity != lsp.SeverityError || d.Range != rng(2, 2, 3) {
+		t.Errorf("diagnostic = %+v, want undefined: y at 2:2-2:3", d)
+	}
+}
+
+func TestDefinition(t *testing.T) {
+	c := startServer(t)
+	open(t, c, testURI, testSrc)
+
+	var loc *lsp.Location
+	if err := c.Call("textDocument/definition", at(13, 7), &loc); err != nil {
+		t.Fatal(err)
+	}
+	if loc == nil || loc.URI != testURI || loc.Range != rng(3, 10, 13) {
+		t.Errorf("definition of add = %+v, want %s 3:10-3:13", loc, testURI)
+	}
+
+	// 关键字上没有定义
+	loc = nil
+	if err := c.Call("textDocument/definition", at(12, 0), &loc); err != nil {
+		t.Fatal(err)
+	}
+	if loc != nil {
+		t.Errorf("definition at begin = %+v, want null", loc)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	c := startServer(t)
+	open(t, c, testURI, testSrc)
+
+	tests := []struct {
+		include bool
+		want    []lsp.Range
+	}{
+		{true, []lsp.Range{rng(1, 4, 9), rng(6, 8, 13), rng(6, 17, 22), rng(9, 2, 7), rng(9, 11, 16), rng(12, 2, 7), rng(14, 7, 12)}},
+		{false, []lsp.Range{rng(6, 8, 13), rng(6, 17, 22), rng(9, 2, 7), rng(9, 11, 16), rng(12, 2, 7), rng(14, 7, 12)}},
+	}
+	for _, tt := range tests {
+		params := &lsp.ReferenceParams{
+			TextDocumentPositionParams: at(12, 3),
+			Context:                    lsp.ReferenceContext{IncludeDeclaration: tt.include},
+		}
+		var locs []lsp.Location
+		if err := c.Call("textDocument/references", params, &locs); err != nil {
+			t.Fatal(err)
+		}
+		if len(locs) != len(tt.want) {
+			t.Errorf("includeDeclaration=%v: %d references, want %d: %+v", tt.include, len(locs), len(tt.want), locs)
+			continue
+		}
+		for i, loc := range locs {
+			if loc.URI != testURI || loc.Range != tt.want[i] {
+				t.Errorf("includeDeclaration=%v: reference %d = %+v, want %+v", tt.include, i, loc, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestHover(t *testing.T) {
+	c := startServer(t)
+	open(t, c, testURI, testSrc)
+
+	tests := []struct {
+		line, char int
+		want       string
+		rng        lsp.Range
+	}{
+		{12, 2, "```pl0\nvar total\n```\n\n累加的结果", rng(12, 2, 7)},
+		{13, 12, "```pl0\nconst step = 2\n```", rng(13, 11, 15)},
+		{13, 7, "```pl0\nprocedure add(n)\n```", rng(13, 7, 10)},
+		{9, 19, "```pl0\nparam n // parameter of add\n```", rng(9, 19, 20)},
+	}
+	for _, tt := range tests {
+		var h *lsp.Hover
+		if err := c.Call("textDocument/hover", at(tt.line, tt.char), &h); err != nil {
+			t.Fatal(err)
+		}
+		if h == nil {
+			t.Errorf("%d:%d: no hover", tt.line, tt.char)
+			continue
+		}
+		if got := strings.TrimSpace(h.Contents.Value); got != tt.want {
+			t.Errorf("%d:%d: hover = %q, want %q", tt.line, tt.char, got, tt.want)
+		}
+		if h.Range == nil || *h.Range != tt.rng {
+			t.Errorf("%d:%d: hover range = %+v, want %+v", tt.line, tt.char, h.Range, tt.rng)
+		}
+	}
+}
+
+func TestDocumentSymbol(t *testing.T) {
+	c := startServer(t)
+	open(t, c, testURI, testSrc)
+
+	var syms []lsp.DocumentSymbol
+	params := &lsp.DocumentSymbolParams{TextDocument: lsp.TextDocumentIdentifier{URI: testURI}}
+	if err := c.Call("textDocument/documentSymbol", params, &syms); err != nil {
+		t.Fatal(err)
+	}
+	if len(syms) != 1 {
+		t.Fatalf("symbols = %+v, want one", syms)
+	}
+	add := syms[0]
+	if add.Name != "add" || add.Detail != "procedure(n)" || add.Kind != lsp.SymbolKindFunction {
+		t.Errorf("symbol = %s %q kind %d, want add \"procedure(n)\" kind %d", add.Name, add.Detail, add.Kind, lsp.SymbolKindFunction)
+	}
+	if add.SelectionRange != rng(3, 10, 13) || add.Range.Start.Line != 3 || add.Range.End.Line != 10 {
+		t.Errorf("add ranges = %+v, %+v", add.Range, add.SelectionRange)
+	}
+	if len(add.Children) != 1 || add.Children[0].Name != "twice" || add.Children[0].Detail != "procedure" {
+		t.Errorf("children of add = %+v, want twice", add.Children)
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	c := startServer(t)
+	open(t, c, testURI, testSrc)
+
+	params := &lsp.DocumentFormattingParams{TextDocument: lsp.TextDocumentIdentifier{URI: testURI}}
+	var edits []lsp.TextEdit
+	if err := c.Call("textDocument/formatting", params, &edits); err != nil {
+		t.Fatal(err)
+	}
+	want, err := format.Source("test.pl", []byte(testSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 1 {
+		t.Fatalf("edits = %+v, want one", edits)
+	}
+	if edits[0].Range != (lsp.Range{End: lsp.Position{Line: 16}}) || edits[0].NewText != string(want) {
+		t.Errorf("edit = %+v, want whole document replaced by %q", edits[0], want)
+	}
+
+	// 已经格式化的文档没有修改
+	open(t, c, testURI, string(want))
+	edits = nil
+	if err := c.Call("textDocument/formatting", params, &edits); err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 0 {
+		t.Errorf("edits of formatted text = %+v, want none", edits)
+	}
+}
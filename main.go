@@ -1,10 +1,19 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"github.com/urfave/cli/v2"
 	"os"
 	"pl0Compiler/build"
+	"pl0Compiler/debugger"
+	"pl0Compiler/format"
+	"pl0Compiler/lexer"
+	"pl0Compiler/lsp"
+	"pl0Compiler/pcode"
+	"pl0Compiler/repl"
+	"pl0Compiler/vet"
 )
 
 func main() {
@@ -16,25 +25,52 @@ func main() {
 	app.Flags = []cli.Flag{
 		&cli.StringFlag{Name: "clang", Usage: "set clang", Value: ""},
 		&cli.BoolFlag{Name: "debug", Aliases: []string{"d"}, Usage: "set debug mode"},
+		&cli.BoolFlag{Name: "check-div", Usage: "report division by zero and overflowing division with the source line at run time"},
+		&cli.BoolFlag{Name: "check-overflow", Usage: "report integer overflow with the source file and line at run time"},
+		&cli.IntFlag{Name: "int-width", Usage: "set integer width in bits, 32 or 64", Value: 32},
+	}
+	app.Before = func(c *cli.Context) error {
+		if w := c.Int("int-width"); w != 32 && w != 64 {
+			fmt.Fprintf(os.Stderr, "invalid int width %d: must be 32 or 64\n", w)
+			os.Exit(1)
+		}
+		return nil
 	}
 
 	app.Commands = []*cli.Command{
 		{
 			Name:  "run",
-			Usage: "compile and run pl/0 program",
+			Usage: "compile and run pl/0 program, made of one or more files",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "interp", Usage: "run with the interpreter instead of clang"},
+			},
 			Action: func(c *cli.Context) error {
-				ctx := build.NewContext(buildOptions(c))
-				output, _ := ctx.Run(c.Args().First(), nil)
+				opt := buildOptions(c)
+				opt.Interp = c.Bool("interp")
+				ctx := build.NewContext(opt)
+				output, err := ctx.Run(c.Args().Slice(), nil)
 				fmt.Print(string(output))
+				if err != nil {
+					if code, ok := exitCode(err); ok {
+						os.Exit(code)
+					}
+					lexer.PrintError(os.Stderr, err)
+					os.Exit(1)
+				}
 				return nil
 			},
 		},
 		{
 			Name:  "build",
-			Usage: "compile pl/0 source code",
+			Usage: "compile pl/0 source files into one program",
 			Action: func(c *cli.Context) error {
 				ctx := build.NewContext(buildOptions(c))
-				ctx.Build(c.Args().First(), nil, "a.out.exe")
+				output, err := ctx.Build(c.Args().Slice(), nil, "a.out.exe")
+				if err != nil {
+					os.Stderr.Write(output)
+					lexer.PrintError(os.Stderr, err)
+					os.Exit(1)
+				}
 				return nil
 			},
 		},
@@ -59,7 +95,7 @@ func main() {
 				ctx := build.NewContext(buildOptions(c))
 				f, err := ctx.AST(c.Args().First(), nil)
 				if err != nil {
-					fmt.Println(err)
+					lexer.PrintError(os.Stdout, err)
 					os.Exit(1)
 				}
 				if c.Bool("json") {
@@ -70,24 +106,241 @@ func main() {
 				return nil
 			},
 		},
+		{
+			Name:      "fmt",
+			Usage:     "reformat pl/0 source files, or stdin when no file is given",
+			ArgsUsage: "[files...]",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "w", Usage: "write result to source file instead of stdout"},
+				&cli.BoolFlag{Name: "d", Usage: "display diffs instead of rewriting files"},
+			},
+			Action: func(c *cli.Context) error {
+				ctx := build.NewContext(buildOptions(c))
+				if c.NArg() == 0 {
+					out, err := ctx.Format("<stdin>", os.Stdin)
+					if err != nil {
+						lexer.PrintError(os.Stderr, err)
+						os.Exit(1)
+					}
+					os.Stdout.Write(out)
+					return nil
+				}
+				failed := false
+				for _, fileName := range c.Args().Slice() {
+					if err := formatFile(ctx, fileName, c.Bool("w"), c.Bool("d")); err != nil {
+						lexer.PrintError(os.Stderr, err)
+						failed = true
+					}
+				}
+				if failed {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "vet",
+			Usage:     "report suspicious constructs in pl/0 program, made of one or more files",
+			ArgsUsage: "[files...]",
+			Flags: []cli.Flag{
+				&cli.StringSliceFlag{Name: "enable", Usage: "run only the named checks"},
+				&cli.StringSliceFlag{Name: "disable", Usage: "skip the named checks"},
+				&cli.BoolFlag{Name: "list", Usage: "list available checks"},
+			},
+			Action: func(c *cli.Context) error {
+				if c.Bool("list") {
+					for _, a := range vet.Analyzers {
+						fmt.Printf("%-12s %s\n", a.Name, a.Doc)
+					}
+					return nil
+				}
+				ctx := build.NewContext(buildOptions(c))
+				diagnostics, err := ctx.Vet(c.Args().Slice(), nil, &vet.Option{
+					Enable:  c.StringSlice("enable"),
+					Disable: c.StringSlice("disable"),
+				})
+				if err != nil {
+					lexer.PrintError(os.Stderr, err)
+					os.Exit(1)
+				}
+				for _, d := range diagnostics {
+					fmt.Fprintln(os.Stderr, d)
+				}
+				if len(diagnostics) != 0 {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "lsp",
+			Usage: "run the pl/0 language server on stdin/stdout",
+			Action: func(c *cli.Context) error {
+				if err := lsp.NewServer(os.Stdin, os.Stdout).Run(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "repl",
+			Usage: "start an interactive pl/0 interpreter",
+			Action: func(c *cli.Context) error {
+				r := repl.New(os.Stdin, os.Stdout)
+				r.SetIntWidth(c.Int("int-width"))
+				if err := r.Run(); err != nil {
+					if code, ok := exitCode(err); ok {
+						os.Exit(code)
+					}
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "debug",
+			Usage:     "debug pl/0 program with the interpreter",
+			ArgsUsage: "files...",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "x", Usage: "read debugger commands from file"},
+			},
+			Action: func(c *cli.Context) error {
+				program, err := build.NewContext(buildOptions(c)).Check(c.Args().Slice(), nil, nil)
+				if err != nil {
+					lexer.PrintError(os.Stderr, err)
+					os.Exit(1)
+				}
+				opt := &debugger.Option{Stdin: os.Stdin, Stdout: os.Stdout, IntWidth: c.Int("int-width")}
+				if script := c.String("x"); script != "" {
+					f, err := os.Open(script)
+					if err != nil {
+						fmt.Fprintln(os.Stderr, err)
+						os.Exit(1)
+					}
+					defer f.Close()
+					opt.Commands, opt.Echo = f, true
+				}
+				if err := debugger.New(program, opt).Run(); err != nil {
+					lexer.PrintError(os.Stderr, err)
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
 		{
 			Name:  "asm",
 			Usage: "parse pl/0 source code and print llvm-ir",
 			Action: func(c *cli.Context) error {
 				ctx := build.NewContext(buildOptions(c))
-				ll, _ := ctx.ASM(c.Args().First(), nil)
+				ll, err := ctx.ASM(c.Args().Slice(), nil)
+				if err != nil {
+					lexer.PrintError(os.Stdout, err)
+					os.Exit(1)
+				}
 				fmt.Println(ll)
 				return nil
 			},
 		},
+		{
+			Name:      "verify",
+			Usage:     "compile each pl/0 file and verify the generated llvm-ir",
+			ArgsUsage: "[files...]",
+			Action: func(c *cli.Context) error {
+				ctx := build.NewContext(buildOptions(c))
+				failed := false
+				for _, fileName := range c.Args().Slice() {
+					if err := ctx.Verify([]string{fileName}, nil); err != nil {
+						fmt.Fprintf(os.Stderr, "%s:\n", fileName)
+						lexer.PrintError(os.Stderr, err)
+						failed = true
+						continue
+					}
+					fmt.Printf("ok\t%s\n", fileName)
+				}
+				if failed {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "pcode",
+			Usage: "compile pl/0 source code and print p-code listing, integers are always 32-bit",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{Name: "run", Usage: "execute p-code on the stack vm"},
+			},
+			Action: func(c *cli.Context) error {
+				ctx := build.NewContext(buildOptions(c))
+				prog, err := ctx.PCode(c.Args().Slice(), nil)
+				if err != nil {
+					lexer.PrintError(os.Stdout, err)
+					os.Exit(1)
+				}
+				if !c.Bool("run") {
+					fmt.Print(pcode.Listing(prog.Code))
+					return nil
+				}
+				if err := pcode.NewVM(prog, os.Stdin, os.Stdout).Run(); err != nil {
+					if code, ok := exitCode(err); ok {
+						os.Exit(code)
+					}
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
 	}
 
 	app.Run(os.Args)
 }
 
+// exitCode 返回程序通过 exit 结束时的退出码
+func exitCode(err error) (int, bool) {
+	var e interface{ ExitCode() int }
+	if errors.As(err, &e) {
+		return e.ExitCode(), true
+	}
+	return 0, false
+}
+
+// formatFile 格式化一个文件, 按 write 和 diff 决定改写文件, 输出差异或输出结果
+func formatFile(ctx *build.Context, fileName string, write, diff bool) error {
+	src, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	out, err := ctx.Format(fileName, src)
+	if err != nil {
+		return err
+	}
+	if diff {
+		os.Stdout.Write(format.Diff("a/"+fileName, "b/"+fileName, src, out))
+	}
+	if write {
+		if bytes.Equal(src, out) {
+			return nil
+		}
+		info, err := os.Stat(fileName)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(fileName, out, info.Mode().Perm())
+	}
+	if !diff {
+		os.Stdout.Write(out)
+	}
+	return nil
+}
+
 func buildOptions(c *cli.Context) *build.Option {
 	return &build.Option{
-		Debug: c.Bool("debug"),
-		Clang: c.String("clang"),
+		Debug:         c.Bool("debug"),
+		Clang:         c.String("clang"),
+		CheckDiv:      c.Bool("check-div"),
+		CheckOverflow: c.Bool("check-overflow"),
+		IntWidth:      c.Int("int-width"),
 	}
 }
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"pl0Compiler/ast"
+	"pl0Compiler/token"
+	"sort"
+	"strings"
+)
+
+// groupComments 把相邻的注释合为一组: 注释之间只有空白且没有空行.
+// 跟在代码之后的注释组只包含同一行中的注释.
+func (p *Parser) groupComments(comments []token.Token) []*ast.CommentGroup {
+	var groups []*ast.CommentGroup
+	var group *ast.CommentGroup
+	for _, tok := range comments {
+		c := &ast.Comment{TextPos: tok.Pos, Text: tok.Literal}
+		if group != nil {
+			between := p.between(group.End(), c.Pos())
+			if strings.TrimSpace(between) != "" || strings.Count(between, "\n") > 1 ||
+				!p.startsLine(group.Pos()) && p.file.Line(group.Pos()) != p.file.Line(c.Pos()) {
+				group = nil
+			}
+		}
+		if group == nil {
+			group = &ast.CommentGroup{}
+			groups = append(groups, group)
+		}
+		group.List = append(group.List, c)
+	}
+	return groups
+}
+
+// attachComments 把注释组关联到声明和语句上.
+//
+// 前导注释独占一行或多行, 与结点之间没有空行; 行尾注释与结点的结束位置在同一行,
+// 中间只能有 ';' 或 '.'. 每个注释组最多关联到一个结点, 外层结点优先.
+func (p *Parser) attachComments(program *ast.Program, groups []*ast.CommentGroup) {
+	if len(groups) == 0 {
+		return
+	}
+	used := make(map[*ast.CommentGroup]bool)
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		doc, comment := commentFields(node)
+		// 只有空语句的语句体没有位置
+		if doc == nil || !node.Pos().IsValid() || !node.End().IsValid() {
+			return true
+		}
+		if g := p.leadComment(groups, node.Pos()); g != nil && !used[g] {
+			*doc, used[g] = g, true
+		}
+		if g := p.lineComment(groups, node.End()); g != nil && !used[g] {
+			*comment, used[g] = g, true
+		}
+		return true
+	})
+}
+
+// leadComment 返回 pos 处结点的前导注释
+func (p *Parser) leadComment(groups []*ast.CommentGroup, pos token.Pos) *ast.CommentGroup {
+	// 结束于 pos 之前的最后一个注释组
+	i := sort.Search(len(groups), func(i int) bool { return groups[i].End() > pos }) - 1
+	if i < 0 {
+		return nil
+	}
+	g := groups[i]
+	between := p.between(g.End(), pos)
+	if strings.TrimSpace(between) != "" || strings.Count(between, "\n") > 1 || !p.startsLine(g.Pos()) {
+		return nil
+	}
+	return g
+}
+
+// lineComment 返回结束于 end 的结点的行尾注释
+func (p *Parser) lineComment(groups []*ast.CommentGroup, end token.Pos) *ast.CommentGroup {
+	// 开始于 end 之后的第一个注释组
+	i := sort.Search(len(groups), func(i int) bool { return groups[i].Pos() >= end })
+	if i == len(groups) {
+		return nil
+	}
+	g := groups[i]
+	if strings.Trim(p.between(end, g.Pos()), " \t;.") != "" {
+		return nil
+	}
+	return g
+}
+
+// between 返回 [from, to) 之间的源代码
+func (p *Parser) between(from, to token.Pos) string {
+	return p.src[p.file.Offset(from):p.file.Offset(to)]
+}
+
+// startsLine 判断 pos 之前同一行中只有空白
+func (p *Parser) startsLine(pos token.Pos) bool {
+	offset := p.file.Offset(pos)
+	lineStart := strings.LastIndexByte(p.src[:offset], '\n') + 1
+	return strings.TrimSpace(p.src[lineStart:offset]) == ""
+}
+
+// commentFields 返回结点的前导注释和行尾注释字段, 不能关联注释的结点返回 nil
+func commentFields(node ast.Node) (doc, comment **ast.CommentGroup) {
+	switch n := node.(type) {
+	case *ast.ImportDecl:
+		return &n.Doc, &n.Comment
+	case *ast.ConstDecl:
+		return &n.Doc, &n.Comment
+	case *ast.VarDecl:
+		return &n.Doc, &n.Comment
+	case *ast.ProcDecl:
+		return &n.Doc, &n.Comment
+	case *ast.BlockStmt:
+		if !n.BeginPos.IsValid() {
+			// 由单条语句构成的块, 注释属于其中的语句
+			return nil, nil
+		}
+		return &n.Doc, &n.Comment
+	case *ast.AssignStmt:
+		return &n.Doc, &n.Comment
+	case *ast.IfStmt:
+		return &n.Doc, &n.Comment
+	case *ast.WhileStmt:
+		return &n.Doc, &n.Comment
+	case *ast.RepeatStmt:
+		return &n.Doc, &n.Comment
+	case *ast.IOStmt:
+		return &n.Doc, &n.Comment
+	case *ast.CallStmt:
+		return &n.Doc, &n.Comment
+	}
+	return nil, nil
+}
@@ -32,21 +32,33 @@ func (p *Parser) parseExprUnary() ast.Expr {
 	if _, ok := p.AcceptToken(token.ADD); ok {
 		return p.parseExprPrimary()
 	}
-	if tok, ok := p.AcceptToken(token.SUB, token.ODD); ok {
+	if tok, ok := p.AcceptToken(token.SUB); ok {
 		return &ast.UnaryExpr{
 			OpPos: tok.Pos,
 			Op:    tok.Type,
 			X:     p.parseExprPrimary(),
 		}
 	}
+	if tok, ok := p.AcceptToken(token.ODD); ok {
+		// odd 作用于其后的整个算术表达式: odd x+1 即 odd (x+1)
+		return &ast.UnaryExpr{
+			OpPos: tok.Pos,
+			Op:    tok.Type,
+			X:     p.parseExprBinary(token.ADD.Precedence()),
+		}
+	}
 	return p.parseExprPrimary()
 }
 
 func (p *Parser) parseExprPrimary() ast.Expr {
-	if _, ok := p.AcceptToken(token.LPAREN); ok {
+	if tokLparen, ok := p.AcceptToken(token.LPAREN); ok {
 		expr := p.parseExpr()
-		p.MustAcceptToken(token.RPAREN)
-		return expr
+		tokRparen := p.MustAcceptToken(token.RPAREN)
+		return &ast.ParenExpr{
+			Lparen: tokLparen.Pos,
+			X:      expr,
+			Rparen: tokRparen.Pos,
+		}
 	}
 
 	switch tok := p.PeekToken(); tok.Type {
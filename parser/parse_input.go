@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"pl0Compiler/ast"
+	"pl0Compiler/lexer"
+	"pl0Compiler/token"
+)
+
+// ParseInput 解析交互式输入中的一组声明和语句, 按输入的顺序返回.
+// 常量, 变量和过程的声明分别为 *ast.ConstDecl, *ast.VarDecl 和 *ast.ProcDecl,
+// 其余为语句. 输入在语法单元的中间结束时 incomplete 为 true,
+// 调用者可以读入更多的行后重新解析.
+func (p *Parser) ParseInput() (nodes []ast.Node, incomplete bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+		}
+		p.errors.RemoveMultiples()
+		incomplete = p.atEOF(p.errors)
+		err = p.errors.Err()
+	}()
+
+	p.interactive = true
+	p.file = p.fset.AddFile(p.fileName, p.src)
+	tokens, comments := lexer.Lex(p.file, p.src)
+	for _, tok := range tokens {
+		if tok.Type == token.ERROR {
+			p.error(tok.Pos, tok.Literal)
+		}
+	}
+
+	p.TokenStream = NewTokenStream(p.fileName, p.src, tokens, comments)
+	for {
+		switch p.PeekToken().Type {
+		case token.EOF, token.ERROR:
+			return
+		case token.SEMICOLON:
+			p.AcceptTokenList(token.SEMICOLON)
+		case token.CONST:
+			nodes = append(nodes, p.parseStmtConst())
+		case token.PROCEDURE:
+			nodes = append(nodes, p.parseProcedure())
+		default:
+			nodes = append(nodes, p.parseBlockItem())
+		}
+	}
+}
+
+// atEOF 报告是否所有的错误都出现在输入的末尾, 即输入只是还没有结束
+func (p *Parser) atEOF(errors lexer.ErrorList) bool {
+	if len(errors) == 0 {
+		return false
+	}
+	for _, e := range errors {
+		if e.Pos.Offset != len(p.src) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseInput 解析交互式输入, 源代码会被添加到 fset 中
+func ParseInput(fset *token.FileSet, fileName, src string) ([]ast.Node, bool, error) {
+	p := NewParser(fset, fileName, src)
+	return p.ParseInput()
+}
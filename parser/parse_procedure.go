@@ -7,42 +7,64 @@ import (
 
 func (p *Parser) parseProcedure() *ast.ProcDecl {
 	tokFunc := p.MustAcceptToken(token.PROCEDURE)
-	tokFuncIdent := p.MustAcceptToken(token.IDENT)
 
 	proc := &ast.ProcDecl{
 		FuncPos: tokFunc.Pos,
-		NamePos: tokFuncIdent.Pos,
-		Name:    tokFuncIdent.Literal,
 		Params:  &ast.FieldList{},
 	}
-	if _, ok := p.AcceptToken(token.LPAREN); ok {
-		for {
-			// args
-			tokArg := p.MustAcceptToken(token.IDENT)
-			proc.Params.List = append(proc.Params.List, &ast.Field{
-				Name: &ast.Ident{
-					NamePos: tokArg.Pos,
-					Name:    tokArg.Literal,
-				},
-			})
-			// )
-			if _, ok := p.AcceptToken(token.RPAREN); ok {
-				break
+
+	// header: name(args);
+	p.tryParse(func() {
+		tokFuncIdent := p.MustAcceptToken(token.IDENT)
+		proc.NamePos = tokFuncIdent.Pos
+		proc.Name = tokFuncIdent.Literal
+
+		if tokLparen, ok := p.AcceptToken(token.LPAREN); ok {
+			proc.Params.Opening = tokLparen.Pos
+			for {
+				// args
+				tokArg := p.MustAcceptToken(token.IDENT)
+				proc.Params.List = append(proc.Params.List, &ast.Field{
+					Name: &ast.Ident{
+						NamePos: tokArg.Pos,
+						Name:    tokArg.Literal,
+					},
+				})
+				// )
+				if tokRparen, ok := p.AcceptToken(token.RPAREN); ok {
+					proc.Params.Closing = tokRparen.Pos
+					break
+				}
+				p.MustAcceptToken(token.COMMA)
 			}
-			p.MustAcceptToken(token.COMMA)
 		}
-	}
-	p.MustAcceptToken(token.SEMICOLON)
+		p.MustAcceptToken(token.SEMICOLON)
+	})
 
 	if _, ok := p.AcceptToken(token.VAR); ok {
 		p.UnreadToken()
-		proc.VarDecl = p.parseStmtVar()
+		p.tryParse(func() {
+			proc.VarDecl = p.parseStmtVar()
+		})
+	}
+
+	// nested procedures
+	for {
+		if _, ok := p.AcceptToken(token.PROCEDURE); !ok {
+			break
+		}
+		p.UnreadToken()
+		p.tryParse(func() {
+			proc.Funcs = append(proc.Funcs, p.parseProcedure())
+		})
 	}
 
 	// body: begin ... end
 	if _, ok := p.AcceptToken(token.BEGIN); ok {
 		p.UnreadToken()
-		proc.Body = p.parseStmtBlock()
+		p.tryParse(func() {
+			proc.Body = p.parseStmtBlock()
+		})
 	}
 	p.MustAcceptToken(token.SEMICOLON)
 
@@ -3,6 +3,7 @@ package parser
 import (
 	"pl0Compiler/ast"
 	"pl0Compiler/token"
+	"strings"
 )
 
 func (p *Parser) parseProgram() {
@@ -10,26 +11,53 @@ func (p *Parser) parseProgram() {
 
 	p.program.FileName = p.fileName
 	p.program.Source = p.src
+	p.program.FileSet = p.fset
 
 	for {
 		switch tok := p.PeekToken(); tok.Type {
 		case token.EOF:
 			return
 		case token.ERROR:
-			panic(tok)
+			// 词法错误已在 ParseProgram 中报告
+			return
 		case token.SEMICOLON:
 			p.AcceptTokenList(token.SEMICOLON)
-		case token.VAR:
-			p.program.Globals = append(p.program.Globals, p.parseStmtVar())
-		case token.CONST:
-			p.program.Const = append(p.program.Const, p.parseStmtConst())
-		case token.PROCEDURE:
-			p.program.Funcs = append(p.program.Funcs, p.parseProcedure())
-		case token.BEGIN:
-			p.program.Stmt = p.parseStmtBlock()
-			p.MustAcceptToken(token.PERIOD)
 		default:
-			p.errorf(tok.Pos, "unknown token: %v", tok)
+			p.tryParse(p.parseDecl)
 		}
 	}
 }
+
+func (p *Parser) parseDecl() {
+	switch tok := p.PeekToken(); tok.Type {
+	case token.IMPORT:
+		p.program.Imports = append(p.program.Imports, p.parseImport())
+	case token.VAR:
+		p.program.Globals = append(p.program.Globals, p.parseStmtVar())
+	case token.CONST:
+		p.program.Const = append(p.program.Const, p.parseStmtConst())
+	case token.PROCEDURE:
+		p.program.Funcs = append(p.program.Funcs, p.parseProcedure())
+	case token.BEGIN:
+		p.program.Stmt = p.parseStmtBlock()
+		p.MustAcceptToken(token.PERIOD)
+	default:
+		p.errorf(tok.Pos, "unknown token: %v", tok)
+	}
+}
+
+func (p *Parser) parseImport() *ast.ImportDecl {
+	tokImport := p.MustAcceptToken(token.IMPORT)
+	tokPath := p.MustAcceptToken(token.STRING)
+	p.MustAcceptToken(token.SEMICOLON)
+
+	path := strings.Trim(tokPath.Literal, `"`)
+	if path == "" {
+		p.errorf(tokPath.Pos, "empty import path")
+	}
+	return &ast.ImportDecl{
+		ImportPos: tokImport.Pos,
+		PathPos:   tokPath.Pos,
+		Path:      path,
+	}
+}
@@ -8,20 +8,17 @@ import (
 func (p *Parser) parseStmt() ast.Stmt {
 	switch tok := p.PeekToken(); tok.Type {
 	case token.EOF:
+		if p.interactive {
+			p.errorf(tok.Pos, "unexpected end of input")
+		}
 		return nil
 	case token.ERROR:
 		p.errorf(tok.Pos, "invalid token: %s", tok.Literal)
 	case token.SEMICOLON:
 		p.AcceptTokenList(token.SEMICOLON)
 		return nil
-	case token.BEGIN: // begin
-		return p.parseStmtBlock()
-	case token.VAR:
-		return p.parseStmtVar()
-	case token.READ, token.WRITE:
-		return p.parseIOStmt()
 	default:
-		return p.parseStmtAssign()
+		return p.parseBlockItem()
 	}
 	panic("unreachable")
 }
@@ -29,11 +26,15 @@ func (p *Parser) parseStmt() ast.Stmt {
 func (p *Parser) parseStmtAssign() ast.Stmt {
 	// expr := expr;
 	target := p.parseExpr()
+	ident, ok := target.(*ast.Ident)
+	if !ok {
+		p.errorf(p.PeekToken().Pos, "cannot assign to %T", target)
+	}
 	tok := p.MustAcceptToken(token.ASSIGN)
 	expr := p.parseExpr()
 	p.MustAcceptToken(token.SEMICOLON)
 	return &ast.AssignStmt{
-		Target: target.(*ast.Ident),
+		Target: ident,
 		OpPos:  tok.Pos,
 		Value:  expr,
 	}
@@ -47,30 +48,16 @@ func (p *Parser) parseStmtBlock() *ast.BlockStmt {
 Loop:
 	for {
 		switch tok := p.PeekToken(); tok.Type {
-		case token.EOF:
+		case token.EOF, token.ERROR:
 			break Loop
-		case token.ERROR:
-			p.errorf(tok.Pos, "invalid token: %s", tok.Literal)
 		case token.SEMICOLON:
 			p.AcceptTokenList(token.SEMICOLON)
-		case token.BEGIN: // begin
-			block.List = append(block.List, p.parseStmtBlock())
-		case token.END: // end
+		case token.END, token.PERIOD: // end
 			break Loop
-		case token.VAR:
-			block.List = append(block.List, p.parseStmtVar())
-		case token.IF:
-			block.List = append(block.List, p.parseStmtIf())
-		case token.WHILE:
-			block.List = append(block.List, p.parseStmtWhile())
-		case token.CALL:
-			block.List = append(block.List, p.parseCall())
-		case token.REPEAT:
-			block.List = append(block.List, p.parseStmtRepeat())
-		case token.READ, token.WRITE:
-			block.List = append(block.List, p.parseIOStmt())
 		default:
-			block.List = append(block.List, p.parseStmtAssign())
+			p.tryParse(func() {
+				block.List = append(block.List, p.parseBlockItem())
+			})
 		}
 	}
 
@@ -82,6 +69,40 @@ Loop:
 	return block
 }
 
+// parseStmtBody 解析 if/while/repeat 的语句体, 没有 begin 时语句体只有一条语句
+func (p *Parser) parseStmtBody() *ast.BlockStmt {
+	tok := p.PeekToken()
+	if tok.Type == token.BEGIN {
+		return p.parseStmtBlock()
+	}
+	block := &ast.BlockStmt{List: []ast.Stmt{p.parseStmt()}}
+	if tok.Type == token.SEMICOLON {
+		block.Semicolon = tok.Pos
+	}
+	return block
+}
+
+func (p *Parser) parseBlockItem() ast.Stmt {
+	switch tok := p.PeekToken(); tok.Type {
+	case token.BEGIN: // begin
+		return p.parseStmtBlock()
+	case token.VAR:
+		return p.parseStmtVar()
+	case token.IF:
+		return p.parseStmtIf()
+	case token.WHILE:
+		return p.parseStmtWhile()
+	case token.CALL:
+		return p.parseCall()
+	case token.REPEAT:
+		return p.parseStmtRepeat()
+	case token.READ, token.WRITE:
+		return p.parseIOStmt()
+	default:
+		return p.parseStmtAssign()
+	}
+}
+
 func (p *Parser) parseStmtExpr() *ast.ExprStmt {
 	return &ast.ExprStmt{
 		X: p.parseExpr(),
@@ -14,27 +14,15 @@ func (p *Parser) parseStmtIf() *ast.IfStmt {
 
 	ifStmt.Cond = p.parseExpr()
 	p.MustAcceptToken(token.THEN)
-	if tok := p.PeekToken(); tok.Type == token.BEGIN {
-		ifStmt.Body = p.parseStmtBlock()
-	} else {
-		stmt := p.parseStmt()
-		ifStmt.Body = &ast.BlockStmt{}
-		ifStmt.Body.List = append(ifStmt.Body.List, stmt)
-	}
+	ifStmt.Body = p.parseStmtBody()
 
-	if _, ok := p.AcceptToken(token.ELSE); ok {
+	if tok, ok := p.AcceptToken(token.ELSE); ok {
+		ifStmt.ElsePos = tok.Pos
 		switch p.PeekToken().Type {
 		case token.IF: // else if
 			ifStmt.Else = p.parseStmtIf()
 		default:
-			if tok := p.PeekToken(); tok.Type == token.BEGIN {
-				ifStmt.Else = p.parseStmtBlock()
-			} else {
-				stmt := p.parseStmt()
-				ifStmt.Else = &ast.BlockStmt{}
-				ifStmt.Else.(*ast.BlockStmt).List = append(ifStmt.Else.(*ast.BlockStmt).List, stmt)
-			}
-			//ifStmt.Else = p.parseStmtBlock()
+			ifStmt.Else = p.parseStmtBody()
 		}
 	}
 
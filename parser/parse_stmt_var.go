@@ -30,13 +30,16 @@ func (p *Parser) parseStmtConst() *ast.ConstDecl {
 		ConstPos: tokConst.Pos,
 	}
 	for {
-		target := p.parseExprPrimary()
+		tokIdent := p.MustAcceptToken(token.IDENT)
 		tok := p.MustAcceptToken(token.EQL)
 		expr := p.parseExpr()
 		constDecl.Definition = append(constDecl.Definition, &ast.DefineStmt{
-			Target: target.(*ast.Ident),
-			OpPos:  tok.Pos,
-			Value:  expr,
+			Target: &ast.Ident{
+				NamePos: tokIdent.Pos,
+				Name:    tokIdent.Literal,
+			},
+			OpPos: tok.Pos,
+			Value: expr,
 		})
 		if _, ok := p.AcceptToken(token.SEMICOLON); ok {
 			break
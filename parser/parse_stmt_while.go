@@ -14,21 +14,7 @@ func (p *Parser) parseStmtWhile() *ast.WhileStmt {
 
 	whileStmt.Cond = p.parseExpr()
 	p.MustAcceptToken(token.DO)
-
-	if tok := p.PeekToken(); tok.Type == token.BEGIN {
-		whileStmt.Body = p.parseStmtBlock()
-	} else {
-		pos := p.PeekToken().Pos
-		stmt := p.parseStmt()
-		stmts := make([]ast.Stmt, 1)
-		stmts[0] = stmt
-		blockStmt := &ast.BlockStmt{
-			BeginPos: pos,
-			EndPos:   pos,
-			List:     stmts,
-		}
-		whileStmt.Body = blockStmt
-	}
+	whileStmt.Body = p.parseStmtBody()
 
 	return whileStmt
 }
@@ -40,22 +26,8 @@ func (p *Parser) parseStmtRepeat() *ast.RepeatStmt {
 		Repeat: tokFor.Pos,
 	}
 
-	if tok := p.PeekToken(); tok.Type == token.BEGIN {
-		repeatStmt.Body = p.parseStmtBlock()
-	} else {
-		pos := p.PeekToken().Pos
-		stmt := p.parseStmt()
-		stmts := make([]ast.Stmt, 1)
-		stmts[0] = stmt
-		blockStmt := &ast.BlockStmt{
-			BeginPos: pos,
-			EndPos:   pos,
-			List:     stmts,
-		}
-		repeatStmt.Body = blockStmt
-	}
-
-	p.MustAcceptToken(token.UNTIL)
+	repeatStmt.Body = p.parseStmtBody()
+	repeatStmt.UntilPos = p.MustAcceptToken(token.UNTIL).Pos
 	repeatStmt.Cond = p.parseExpr()
 
 	return repeatStmt
@@ -8,47 +8,166 @@ import (
 )
 
 type Parser struct {
+	fset     *token.FileSet
+	file     *token.File
 	fileName string
 	src      string
 
 	*TokenStream
 	program *ast.Program
-	err     error
+	errors  lexer.ErrorList
+	syncing bool // 出错后还没有在新的语句或声明处恢复, 期间的错误是连带的, 不报告
+
+	// interactive 为 true 时语句不能在输入末尾省略, 使交互式输入可以继续读入下一行
+	interactive bool
+}
+
+// bailout 用于在出错后退出当前的语法单元, 由 tryParse 恢复
+type bailout struct{}
+
+func (p *Parser) error(pos token.Pos, msg string) {
+	p.errors.Add(p.fset.Position(pos), msg)
 }
 
 func (p *Parser) errorf(pos token.Pos, format string, args ...interface{}) {
-	p.err = fmt.Errorf("%s: %s", lexer.PosString(p.fileName, p.src, int(pos)), fmt.Sprintf(format, args...))
-	panic(p.err)
+	if !p.syncing {
+		p.error(pos, fmt.Sprintf(format, args...))
+		p.syncing = true
+	}
+	panic(bailout{})
+}
+
+// MustAcceptToken 与 TokenStream.MustAcceptToken 相同, 但出错时记录位置并进入错误恢复
+func (p *Parser) MustAcceptToken(expectTypes ...token.TokenType) (tok token.Token) {
+	tok, ok := p.AcceptToken(expectTypes...)
+	if !ok {
+		if tok.Type == token.ERROR {
+			// 词法错误已经报告过
+			panic(bailout{})
+		}
+		p.errorf(tok.Pos, "expect %v, got %q", expectTypes, tok.Literal)
+	}
+	return tok
+}
+
+// tryParse 执行 parse, 出错时跳过记号直到 ';', 'end', '.' 或下一条语句的开始以继续解析.
+// parse 开始于新的语句或声明, 之后的错误重新开始报告.
+func (p *Parser) tryParse(parse func()) {
+	p.syncing = false
+	start := p.pos
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+			p.sync()
+			if p.pos == start {
+				// 保证每次出错后至少前进一个记号
+				p.ReadToken()
+			}
+		}
+	}()
+	parse()
+}
+
+// sync 跳过记号直到 ';' (同时跳过), 'end', '.', 文件结束或开始一条语句的关键字.
+// 在 begin 处停下, 出错的语句中嵌套的块不会被当作外层块的一部分.
+func (p *Parser) sync() {
+	for {
+		switch p.PeekToken().Type {
+		case token.SEMICOLON:
+			p.ReadToken()
+			return
+		case token.END, token.PERIOD, token.EOF, token.ERROR:
+			return
+		case token.BEGIN, token.IF, token.WHILE, token.REPEAT, token.CALL, token.READ, token.WRITE:
+			return
+		}
+		p.ReadToken()
+	}
 }
 
 func (p *Parser) ParseProgram() (file *ast.Program, err error) {
 	defer func() {
-		if r := recover(); r != p.err {
-			panic(r)
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
 		}
-		file, err = p.program, p.err
+		p.errors.RemoveMultiples()
+		file, err = p.program, p.errors.Err()
 	}()
 
-	tokens, comments := lexer.Lex(p.fileName, p.src)
+	p.file = p.fset.AddFile(p.fileName, p.src)
+	tokens, comments := lexer.Lex(p.file, p.src)
 	for _, tok := range tokens {
 		if tok.Type == token.ERROR {
-			p.errorf(tok.Pos, "invalid token: %s", tok.Literal)
+			p.error(tok.Pos, tok.Literal)
 		}
 	}
 
 	p.TokenStream = NewTokenStream(p.fileName, p.src, tokens, comments)
 	p.parseProgram()
+
+	p.program.Comments = p.groupComments(comments)
+	p.attachComments(p.program, p.program.Comments)
 	return
 }
 
-func NewParser(fileName, src string) *Parser {
+// NewParser 创建解析器, 源文件会被添加到 fset 中
+func NewParser(fset *token.FileSet, fileName, src string) *Parser {
 	return &Parser{
+		fset:     fset,
 		fileName: fileName,
 		src:      src,
 	}
 }
 
-func ParseFile(fileName, src string) (*ast.Program, error) {
-	p := NewParser(fileName, src)
+// ParseFile 解析源文件, 位置信息记录在 fset 中
+func ParseFile(fset *token.FileSet, fileName, src string) (*ast.Program, error) {
+	p := NewParser(fset, fileName, src)
 	return p.ParseProgram()
 }
+
+// ParseFiles 解析组成同一个程序的多个源文件, 合并其中的常量, 变量和过程.
+// 最多只有一个文件可以包含主程序 begin ... end.
+func ParseFiles(fset *token.FileSet, fileNames, srcs []string) (*ast.Program, error) {
+	var (
+		program = &ast.Program{FileSet: fset}
+		errors  lexer.ErrorList
+	)
+	for i, fileName := range fileNames {
+		f, err := ParseFile(fset, fileName, srcs[i])
+		if err != nil {
+			list, ok := err.(lexer.ErrorList)
+			if !ok {
+				return nil, err
+			}
+			errors = append(errors, list...)
+		}
+		if f == nil {
+			continue
+		}
+
+		program.Imports = append(program.Imports, f.Imports...)
+		program.Comments = append(program.Comments, f.Comments...)
+		program.Const = append(program.Const, f.Const...)
+		program.Globals = append(program.Globals, f.Globals...)
+		program.Funcs = append(program.Funcs, f.Funcs...)
+		if f.Stmt != nil {
+			if program.Stmt != nil {
+				errors.Add(fset.Position(f.Stmt.Pos()), fmt.Sprintf(
+					"main block redeclared, previous declaration at %s",
+					fset.Position(program.Stmt.Pos())))
+				continue
+			}
+			program.Stmt = f.Stmt
+			program.FileName, program.Source = f.FileName, f.Source
+		}
+		if program.FileName == "" {
+			program.FileName, program.Source = f.FileName, f.Source
+		}
+	}
+	errors.Sort()
+	return program, errors.Err()
+}
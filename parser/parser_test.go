@@ -0,0 +1,266 @@
+package parser_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pl0Compiler/ast"
+	"pl0Compiler/lexer"
+	"pl0Compiler/parser"
+	"pl0Compiler/token"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// demoFiles 返回 demo 目录下的所有源文件
+func demoFiles(t *testing.T) []string {
+	t.Helper()
+	var files []string
+	for _, pattern := range []string{"*.pl", "corpus/*.pl", "lib/*.pl", "multi/*.pl"} {
+		m, err := filepath.Glob(filepath.Join("../demo", pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, m...)
+	}
+	if len(files) == 0 {
+		t.Fatal("no demo files")
+	}
+	return files
+}
+
+// bodySrc 的 if/while/repeat 语句体没有 begin, 语句体是 call/if/while 等语句
+const bodySrc = `var x, n;
+procedure p;
+begin
+  n := n + 10;
+end;
+begin
+  if x = 0 then call p;
+  while x < 4 do
+    if odd x then x := x + 1; else x := (x + 3);
+  repeat
+    while x > 0 do x := x - 1;
+  until x = 0;
+  if n > 0 then read n; else write n;
+end.
+`
+
+// TestNodePositions 检查每个结点的范围都在文件内, Pos <= End,
+// 并且结点的范围对应源代码中的文本
+func TestNodePositions(t *testing.T) {
+	files := map[string]string{"body.pl": bodySrc}
+	for _, fileName := range demoFiles(t) {
+		src, err := os.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[fileName] = string(src)
+	}
+	for fileName, src := range files {
+		fset := token.NewFileSet()
+		program, err := parser.ParseFile(fset, fileName, src)
+		if err != nil {
+			t.Errorf("%s: %v", fileName, err)
+			continue
+		}
+		file := fset.File(program.Pos())
+		if file == nil {
+			t.Errorf("%s: program has no position", fileName)
+			continue
+		}
+		base, end := token.Pos(file.Base()), token.Pos(file.Base()+file.Size())
+		ast.Inspect(program, func(n ast.Node) bool {
+			if n == nil {
+				return false
+			}
+			pos, nodeEnd := n.Pos(), n.End()
+			if !pos.IsValid() || pos < base || nodeEnd > end || pos > nodeEnd {
+				t.Errorf("%s: %T at %v: Pos %d, End %d, file [%d, %d]",
+					fileName, n, fset.Position(pos), pos, nodeEnd, base, end)
+				return false
+			}
+			text := src[file.Offset(pos):file.Offset(nodeEnd)]
+			if want, exact := nodeText(n); !strings.HasPrefix(text, want) || exact && text != want {
+				t.Errorf("%s: %T at %v: source %q, want %q", fileName, n, fset.Position(pos), text, want)
+			}
+			return true
+		})
+	}
+}
+
+// nodeText 返回结点的源代码开头的文本, exact 为真时是结点的全部文本
+func nodeText(n ast.Node) (text string, exact bool) {
+	switch n := n.(type) {
+	case *ast.Ident:
+		return n.Name, true
+	case *ast.Number:
+		return strconv.Itoa(n.Value), true
+	case *ast.ImportDecl:
+		return "import", false
+	case *ast.ConstDecl:
+		return "const", false
+	case *ast.VarDecl:
+		return "var", false
+	case *ast.ProcDecl:
+		return "procedure", false
+	case *ast.BlockStmt:
+		if n.BeginPos.IsValid() {
+			return "begin", false
+		}
+	case *ast.IfStmt:
+		return "if", false
+	case *ast.WhileStmt:
+		return "while", false
+	case *ast.RepeatStmt:
+		return "repeat", false
+	case *ast.CallStmt:
+		return "call", false
+	case *ast.IOStmt:
+		return n.Type.String(), false
+	case *ast.UnaryExpr:
+		return n.Op.String(), false
+	case *ast.ParenExpr:
+		return "(", false
+	}
+	return "", false
+}
+
+// TestStmtBody 检查没有 begin 的语句体可以是任意一条语句
+func TestStmtBody(t *testing.T) {
+	program, err := parser.ParseFile(token.NewFileSet(), "body.pl", bodySrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, stmt := range program.Stmt.List {
+		switch stmt := stmt.(type) {
+		case *ast.IfStmt:
+			got = append(got, fmt.Sprintf("if %T else %T", stmt.Body.List[0], stmt.Else))
+		case *ast.WhileStmt:
+			got = append(got, fmt.Sprintf("while %T", stmt.Body.List[0]))
+		case *ast.RepeatStmt:
+			got = append(got, fmt.Sprintf("repeat %T", stmt.Body.List[0]))
+		}
+	}
+	want := []string{
+		"if *ast.CallStmt else <nil>",
+		"while *ast.IfStmt",
+		"repeat *ast.WhileStmt",
+		"if *ast.IOStmt else *ast.BlockStmt",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statement bodies:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+// commentSrc 中的注释关联到声明和语句上, 与结点之间有空行, 在 begin 之后或在表达式中的注释不关联
+const commentSrc = `// 文件开头的注释, 与 var 之间有空行
+
+// 计数器
+var n; // 行尾
+{ 第一行 }
+(* 第二行 *)
+const c = 1;
+
+// 过程 p
+procedure p;
+begin // begin 之后
+  n := n + 1; // 加一
+end; // 过程结束
+
+begin
+  // 调用
+  call p;
+  if n > c (* 条件中 *) then
+    // 单条语句的语句体
+    read n; // 读
+  while n > 0 do
+  begin
+    n := n - 1;
+  end; // 循环结束
+  repeat
+    n := n + 1;
+  until n = 3; { until 之后 }
+end. // 程序结束
+`
+
+// TestCommentAttachment 检查每个注释组关联到的结点和字段
+func TestCommentAttachment(t *testing.T) {
+	fset := token.NewFileSet()
+	program, err := parser.ParseFile(fset, "test.pl", commentSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(program.Comments) != 15 {
+		t.Errorf("%d comment groups, want 15", len(program.Comments))
+	}
+	var got []string
+	ast.Inspect(program, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		v := reflect.ValueOf(n).Elem()
+		for _, field := range []string{"Doc", "Comment"} {
+			f := v.FieldByName(field)
+			if !f.IsValid() || f.IsNil() {
+				continue
+			}
+			var text []string
+			for _, c := range f.Interface().(*ast.CommentGroup).List {
+				text = append(text, c.Text)
+			}
+			got = append(got, fmt.Sprintf("%s %T %s: %s", fset.Position(n.Pos()), n, field, strings.Join(text, " | ")))
+		}
+		return true
+	})
+	want := []string{
+		"test.pl:7:1 *ast.ConstDecl Doc: { 第一行 } | (* 第二行 *)",
+		"test.pl:4:1 *ast.VarDecl Doc: // 计数器",
+		"test.pl:4:1 *ast.VarDecl Comment: // 行尾",
+		"test.pl:10:1 *ast.ProcDecl Doc: // 过程 p",
+		"test.pl:10:1 *ast.ProcDecl Comment: // 过程结束",
+		"test.pl:12:3 *ast.AssignStmt Comment: // 加一",
+		"test.pl:15:1 *ast.BlockStmt Comment: // 程序结束",
+		"test.pl:17:3 *ast.CallStmt Doc: // 调用",
+		// 外层结点优先, 语句体的行尾注释属于 if
+		"test.pl:18:3 *ast.IfStmt Comment: // 读",
+		"test.pl:20:5 *ast.IOStmt Doc: // 单条语句的语句体",
+		"test.pl:21:3 *ast.WhileStmt Comment: // 循环结束",
+		"test.pl:25:3 *ast.RepeatStmt Comment: { until 之后 }",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("comments:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+// TestErrorRecovery 检查出错后恢复解析时不报告连带的错误
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		src  string
+		want int // 错误的个数
+	}{
+		// 嵌套的块中 else 之前多了 ';'
+		{"var x;\nbegin\n  begin\n    if x = 1 then begin x := 2; end;\n    else begin x := 3; end;\n    x := 4;\n  end;\nend.", 1},
+		// 循环体中 else 之前缺少 ';'
+		{"var x;\nbegin\n  while x < 3 do\n  begin\n    if x = 1 then x := 2\n    else begin x := 3; x := 5; end;\n    x := 4;\n  end;\nend.", 1},
+		// 互不相关的三个错误
+		{"var x;\nbegin\n  begin x := 2 end;\n  x := 4\n  call p;\n  x := ;\nend.", 3},
+		{"var x y;\nbegin\n  x := 1;\nend.", 1},
+		{"var x;\nbegin\n  x := 1;\nend", 1},
+	}
+	for _, tt := range tests {
+		_, err := parser.ParseFile(token.NewFileSet(), "test.pl", tt.src)
+		var list lexer.ErrorList
+		if err != nil && !errors.As(err, &list) {
+			t.Errorf("%q: error %v is not a lexer.ErrorList", tt.src, err)
+			continue
+		}
+		if len(list) != tt.want {
+			t.Errorf("%q: got %d errors, want %d:\n%v", tt.src, len(list), tt.want, err)
+		}
+	}
+}
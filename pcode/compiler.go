@@ -0,0 +1,454 @@
+package pcode
+
+import (
+	"fmt"
+	"pl0Compiler/ast"
+	"pl0Compiler/constant"
+	"pl0Compiler/token"
+)
+
+// 活动记录中静态链, 动态链和返回地址占用的单元数
+const frameHeader = 3
+
+// builtins 内置的过程对应的运算, 与 compiler.Universe 一致
+var builtins = map[string]int{
+	"println": OprPrint,
+	"exit":    OprExit,
+}
+
+type symKind int
+
+const (
+	symVar symKind = iota
+	symConst
+	symProc
+)
+
+type symbol struct {
+	name  string
+	kind  symKind
+	level int   // 声明所在的层次
+	addr  int   // 变量在活动记录中的偏移, 过程的入口地址
+	value int64 // 常量的值
+	calls []int // 过程入口确定前需要回填的 CAL 指令
+}
+
+type scope struct {
+	outer   *scope
+	symbols map[string]*symbol
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{outer, make(map[string]*symbol)}
+}
+
+func (s *scope) lookup(name string) *symbol {
+	for ; s != nil; s = s.outer {
+		if sym := s.symbols[name]; sym != nil {
+			return sym
+		}
+	}
+	return nil
+}
+
+func (s *scope) insert(sym *symbol) {
+	if _, ok := s.symbols[sym.name]; !ok {
+		s.symbols[sym.name] = sym
+	}
+}
+
+// frame 记录正在生成的过程的活动记录信息
+type frame struct {
+	level int
+	size  int
+}
+
+// Compiler 把语法树翻译为 Wirth 的 p-code.
+type Compiler struct {
+	program *ast.Program
+	scope   *scope
+	modules map[*ast.Program]*scope // 导入的模块的顶层作用域
+	frame   *frame
+	procs   []*symbol
+	code    []Instr
+	lines   []token.Pos // 每条指令的源代码位置
+	pos     token.Pos   // 正在生成的语句或运算的位置
+	err     error
+}
+
+func NewCompiler() *Compiler {
+	return &Compiler{}
+}
+
+func (p *Compiler) Compile(program *ast.Program) (prog *Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r != p.err {
+				panic(r)
+			}
+			prog, err = nil, p.err
+		}
+	}()
+
+	p.program = program
+	p.compileProgram(program)
+	return &Program{Code: p.code, Pos: p.lines, FileSet: program.FileSet}, nil
+}
+
+func (p *Compiler) errorf(pos token.Pos, format string, args ...interface{}) {
+	p.err = fmt.Errorf("%s: %s",
+		p.program.FileSet.Position(pos), fmt.Sprintf(format, args...))
+	panic(p.err)
+}
+
+func (p *Compiler) emit(op Op, l, a int) int {
+	p.code = append(p.code, Instr{Op: op, L: l, A: a})
+	p.lines = append(p.lines, p.pos)
+	return len(p.code) - 1
+}
+
+func (p *Compiler) compileProgram(program *ast.Program) {
+	p.modules = make(map[*ast.Program]*scope)
+	p.frame = &frame{level: 0, size: frameHeader}
+
+	jmp := p.emit(JMP, 0, 0)
+	p.scope = p.compileDecls(program)
+
+	// main
+	p.code[jmp].A = len(p.code)
+	size := p.emit(INT, 0, 0)
+	if program.Stmt != nil {
+		p.compileStmt(program.Stmt)
+	}
+	p.code[size].A = p.frame.size
+	p.emit(OPR, 0, OprRet)
+
+	for _, sym := range p.procs {
+		if sym.addr < 0 && len(sym.calls) != 0 {
+			p.errorf(token.NoPos, "proc %s has no body", sym.name)
+		}
+	}
+}
+
+// compileDecls 生成程序的全局变量, 常量和过程, 返回顶层作用域.
+// 导入的模块的变量同样分配在主程序的活动记录中.
+func (p *Compiler) compileDecls(program *ast.Program) *scope {
+	defer func(s *scope) { p.scope = s }(p.scope)
+
+	imports := newScope(nil)
+	for _, imp := range program.Imports {
+		for _, sym := range p.compileModule(imp.Module).symbols {
+			imports.insert(sym)
+		}
+	}
+	p.scope = newScope(imports)
+
+	for _, g := range program.Globals {
+		p.declareVar(g)
+	}
+
+	for _, c := range program.Const {
+		for _, def := range c.Definition {
+			p.scope.insert(&symbol{
+				name:  def.Target.Name,
+				kind:  symConst,
+				value: p.constValue(def),
+			})
+		}
+	}
+
+	p.compileProcedures(program.Funcs)
+	return p.scope
+}
+
+// compileModule 生成导入的模块, 每个模块只生成一次
+func (p *Compiler) compileModule(module *ast.Program) *scope {
+	if s, ok := p.modules[module]; ok {
+		return s
+	}
+	s := p.compileDecls(module)
+	p.modules[module] = s
+	return s
+}
+
+// compileProcedures 在当前作用域中声明并生成一组同层的过程
+func (p *Compiler) compileProcedures(funcs []*ast.ProcDecl) {
+	syms := make([]*symbol, len(funcs))
+	for i, fn := range funcs {
+		syms[i] = &symbol{
+			name:  fn.Name,
+			kind:  symProc,
+			level: p.frame.level,
+			addr:  -1,
+		}
+		p.scope.insert(syms[i])
+		p.procs = append(p.procs, syms[i])
+	}
+
+	for i, fn := range funcs {
+		p.compileProcedure(fn, syms[i])
+	}
+}
+
+func (p *Compiler) constValue(def *ast.DefineStmt) int64 {
+	value, err := constant.Eval(def.Value, func(name string) (int64, bool) {
+		sym := p.scope.lookup(name)
+		if sym == nil || sym.kind != symConst {
+			return 0, false
+		}
+		return sym.value, true
+	})
+	if err != nil {
+		p.errorf(err.(*constant.Error).Pos, "const %s: %v", def.Target.Name, err)
+	}
+	return value
+}
+
+func (p *Compiler) declareVar(decl *ast.VarDecl) {
+	for _, name := range decl.Names {
+		p.scope.insert(&symbol{
+			name:  name.Name,
+			kind:  symVar,
+			level: p.frame.level,
+			addr:  p.frame.size,
+		})
+		p.frame.size++
+	}
+}
+
+func (p *Compiler) compileProcedure(fn *ast.ProcDecl, sym *symbol) {
+	if fn.Body == nil {
+		return
+	}
+
+	outerScope, outerFrame, outerPos := p.scope, p.frame, p.pos
+	defer func() {
+		p.scope, p.frame, p.pos = outerScope, outerFrame, outerPos
+	}()
+	p.pos = fn.Pos()
+
+	sym.addr = len(p.code)
+	for _, call := range sym.calls {
+		p.code[call].A = sym.addr
+	}
+	sym.calls = nil
+
+	p.scope = newScope(p.scope)
+	p.frame = &frame{level: outerFrame.level + 1, size: frameHeader}
+
+	// 实参由调用者压栈, 位于活动记录之前
+	n := len(fn.Params.List)
+	for i, arg := range fn.Params.List {
+		p.scope.insert(&symbol{
+			name:  arg.Name.Name,
+			kind:  symVar,
+			level: p.frame.level,
+			addr:  i - n,
+		})
+	}
+
+	if fn.VarDecl != nil {
+		p.declareVar(fn.VarDecl)
+	}
+
+	// 嵌套过程的代码位于过程体之前, 入口处跳过它们
+	if len(fn.Funcs) != 0 {
+		jmp := p.emit(JMP, 0, 0)
+		p.compileProcedures(fn.Funcs)
+		p.code[jmp].A = len(p.code)
+	}
+
+	size := p.emit(INT, 0, 0)
+	if fn.VarDecl != nil {
+		for _, name := range fn.VarDecl.Names {
+			sym := p.scope.lookup(name.Name)
+			p.emit(LIT, 0, 0)
+			p.emit(STO, 0, sym.addr)
+		}
+	}
+	for _, x := range fn.Body.List {
+		p.compileStmt(x)
+	}
+	p.code[size].A = p.frame.size
+	p.emit(OPR, 0, OprRet)
+}
+
+func (p *Compiler) compileStmt(stmt ast.Stmt) {
+	if stmt != nil {
+		defer func(pos token.Pos) { p.pos = pos }(p.pos)
+		p.pos = stmt.Pos()
+	}
+	switch stmt := stmt.(type) {
+	case nil:
+		// 空语句, 例如 if c then ;
+	case *ast.VarDecl:
+		p.declareVar(stmt)
+		for _, name := range stmt.Names {
+			sym := p.scope.lookup(name.Name)
+			p.emit(LIT, 0, 0)
+			p.emit(STO, p.frame.level-sym.level, sym.addr)
+		}
+	case *ast.AssignStmt:
+		p.compileExpr(stmt.Value)
+		sym := p.lookupVar(stmt.Target)
+		p.emit(STO, p.frame.level-sym.level, sym.addr)
+	case *ast.IfStmt:
+		p.compileExpr(stmt.Cond)
+		jpc := p.emit(JPC, 0, 0)
+		p.compileStmt(stmt.Body)
+		if stmt.Else != nil {
+			jmp := p.emit(JMP, 0, 0)
+			p.code[jpc].A = len(p.code)
+			p.compileStmt(stmt.Else)
+			p.code[jmp].A = len(p.code)
+		} else {
+			p.code[jpc].A = len(p.code)
+		}
+	case *ast.WhileStmt:
+		cond := len(p.code)
+		p.compileExpr(stmt.Cond)
+		jpc := p.emit(JPC, 0, 0)
+		p.compileStmt(stmt.Body)
+		p.emit(JMP, 0, cond)
+		p.code[jpc].A = len(p.code)
+	case *ast.RepeatStmt:
+		body := len(p.code)
+		p.compileStmt(stmt.Body)
+		p.compileExpr(stmt.Cond)
+		p.emit(JPC, 0, body)
+	case *ast.BlockStmt:
+		defer func(s *scope) { p.scope = s }(p.scope)
+		p.scope = newScope(p.scope)
+
+		for _, x := range stmt.List {
+			p.compileStmt(x)
+		}
+	case *ast.ExprStmt:
+		p.compileExpr(stmt.X)
+		p.emit(INT, 0, -1)
+	case *ast.CallStmt:
+		p.compileStmtCall(stmt)
+	case *ast.IOStmt:
+		switch stmt.Type {
+		case token.READ:
+			for _, param := range stmt.Params.List {
+				p.compileExpr(param.Name)
+				p.emit(OPR, 0, OprPrint)
+			}
+		case token.WRITE:
+			sym := p.lookupVar(stmt.Params.List[0].Name)
+			p.emit(OPR, 0, OprInput)
+			p.emit(STO, p.frame.level-sym.level, sym.addr)
+		}
+
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", stmt))
+	}
+}
+
+func (p *Compiler) compileStmtCall(stmt *ast.CallStmt) {
+	sym := p.scope.lookup(stmt.ProcedureName.Name)
+	if op, ok := builtins[stmt.ProcedureName.Name]; ok && sym == nil {
+		// 内置的过程直接翻译为运算指令
+		if len(stmt.Args) != 1 {
+			p.errorf(stmt.ProcedureName.NamePos, "proc %s expects 1 argument, got %d",
+				stmt.ProcedureName.Name, len(stmt.Args))
+		}
+		p.compileExpr(stmt.Args[0])
+		p.emit(OPR, 0, op)
+		return
+	}
+	if sym == nil {
+		p.errorf(stmt.ProcedureName.NamePos, "proc %s undefined", stmt.ProcedureName.Name)
+	}
+	if sym.kind != symProc {
+		p.errorf(stmt.ProcedureName.NamePos, "%s is not a procedure", sym.name)
+	}
+
+	for _, arg := range stmt.Args {
+		p.compileExpr(arg)
+	}
+	call := p.emit(CAL, p.frame.level-sym.level, sym.addr)
+	if sym.addr < 0 {
+		sym.calls = append(sym.calls, call)
+	}
+	if len(stmt.Args) != 0 {
+		p.emit(INT, 0, -len(stmt.Args))
+	}
+}
+
+func (p *Compiler) compileExpr(expr ast.Expr) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		sym := p.scope.lookup(expr.Name)
+		if sym == nil {
+			p.errorf(expr.NamePos, "var %s undefined", expr.Name)
+		}
+		switch sym.kind {
+		case symConst:
+			p.emit(LIT, 0, int(sym.value))
+		case symVar:
+			p.emit(LOD, p.frame.level-sym.level, sym.addr)
+		default:
+			p.errorf(expr.NamePos, "proc %s used as value", expr.Name)
+		}
+	case *ast.Number:
+		p.emit(LIT, 0, expr.Value)
+	case *ast.BinaryExpr:
+		p.compileExpr(expr.X)
+		p.compileExpr(expr.Y)
+		// 运算指令使用运算符的位置, 除数为零时报告
+		defer func(pos token.Pos) { p.pos = pos }(p.pos)
+		p.pos = expr.OpPos
+		switch expr.Op {
+		case token.ADD:
+			p.emit(OPR, 0, OprAdd)
+		case token.SUB:
+			p.emit(OPR, 0, OprSub)
+		case token.MUL:
+			p.emit(OPR, 0, OprMul)
+		case token.DIV:
+			p.emit(OPR, 0, OprDiv)
+		case token.MOD:
+			p.emit(OPR, 0, OprMod)
+		case token.EQL: // =
+			p.emit(OPR, 0, OprEql)
+		case token.NEQ: // <>
+			p.emit(OPR, 0, OprNeq)
+		case token.LSS: // <
+			p.emit(OPR, 0, OprLss)
+		case token.LEQ: // <=
+			p.emit(OPR, 0, OprLeq)
+		case token.GTR: // >
+			p.emit(OPR, 0, OprGtr)
+		case token.GEQ: // >=
+			p.emit(OPR, 0, OprGeq)
+		default:
+			panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
+		}
+	case *ast.UnaryExpr:
+		p.compileExpr(expr.X)
+		switch expr.Op {
+		case token.SUB:
+			p.emit(OPR, 0, OprNeg)
+		case token.ODD:
+			p.emit(OPR, 0, OprOdd)
+		}
+	case *ast.ParenExpr:
+		p.compileExpr(expr.X)
+
+	default:
+		panic(fmt.Sprintf("unknown: %[1]T, %[1]v", expr))
+	}
+}
+
+func (p *Compiler) lookupVar(ident *ast.Ident) *symbol {
+	sym := p.scope.lookup(ident.Name)
+	if sym == nil {
+		p.errorf(ident.NamePos, "var %s undefined", ident.Name)
+	}
+	if sym.kind != symVar {
+		p.errorf(ident.NamePos, "cannot assign to %s", ident.Name)
+	}
+	return sym
+}
@@ -0,0 +1,128 @@
+package pcode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"pl0Compiler/token"
+	"strconv"
+)
+
+// Op p-code 指令的功能码
+type Op int
+
+const (
+	LIT Op = iota // LIT 0, a: 常量 a 压栈
+	OPR           // OPR 0, a: 执行运算 a
+	LOD           // LOD l, a: 层差为 l, 偏移为 a 的变量压栈
+	STO           // STO l, a: 栈顶存入层差为 l, 偏移为 a 的变量
+	CAL           // CAL l, a: 调用地址为 a 的过程, l 为层差
+	INT           // INT 0, a: 栈顶指针增加 a
+	JMP           // JMP 0, a: 无条件跳转到 a
+	JPC           // JPC 0, a: 栈顶为 0 时跳转到 a
+)
+
+var ops = [...]string{
+	LIT: "LIT",
+	OPR: "OPR",
+	LOD: "LOD",
+	STO: "STO",
+	CAL: "CAL",
+	INT: "INT",
+	JMP: "JMP",
+	JPC: "JPC",
+}
+
+func (op Op) String() string {
+	if 0 <= op && op < Op(len(ops)) {
+		return ops[op]
+	}
+	return "op(" + strconv.Itoa(int(op)) + ")"
+}
+
+// OPR 指令的运算类型
+const (
+	OprRet   = 0  // 过程返回
+	OprNeg   = 1  // 取负
+	OprAdd   = 2  // +
+	OprSub   = 3  // -
+	OprMul   = 4  // *
+	OprDiv   = 5  // /
+	OprOdd   = 6  // odd
+	OprEql   = 8  // =
+	OprNeq   = 9  // <>
+	OprLss   = 10 // <
+	OprGeq   = 11 // >=
+	OprGtr   = 12 // >
+	OprLeq   = 13 // <=
+	OprPrint = 14 // 输出栈顶并换行
+	OprInput = 15 // 读入一个整数压栈
+	OprExit  = 16 // 以栈顶的值为退出码结束程序
+	OprMod   = 17 // %
+)
+
+var oprs = map[int]string{
+	OprRet:   "ret",
+	OprNeg:   "neg",
+	OprAdd:   "add",
+	OprSub:   "sub",
+	OprMul:   "mul",
+	OprDiv:   "div",
+	OprOdd:   "odd",
+	OprEql:   "eql",
+	OprNeq:   "neq",
+	OprLss:   "lss",
+	OprGeq:   "geq",
+	OprGtr:   "gtr",
+	OprLeq:   "leq",
+	OprPrint: "print",
+	OprInput: "input",
+	OprExit:  "exit",
+	OprMod:   "mod",
+}
+
+// Instr 一条 p-code 指令
+type Instr struct {
+	Op Op  // 功能码
+	L  int // 层差
+	A  int // 地址/常量/运算类型
+}
+
+func (i Instr) String() string {
+	s := fmt.Sprintf("%s %d, %d", i.Op, i.L, i.A)
+	if i.Op == OPR {
+		if name, ok := oprs[i.A]; ok {
+			s += " ; " + name
+		}
+	}
+	return s
+}
+
+// Program 编译得到的 p-code 及其源代码位置表
+type Program struct {
+	Code    []Instr
+	Pos     []token.Pos    // 每条指令来自的源代码位置, 与 Code 一一对应
+	FileSet *token.FileSet // Pos 所在的文件集
+}
+
+// Position 返回第 pc 条指令对应的源代码位置, 没有位置时返回无效的位置
+func (p *Program) Position(pc int) token.Position {
+	if p.FileSet == nil || pc < 0 || pc >= len(p.Pos) {
+		return token.Position{}
+	}
+	return p.FileSet.Position(p.Pos[pc])
+}
+
+// Fprint 打印指令清单
+func Fprint(w io.Writer, code []Instr) {
+	for i, x := range code {
+		_, _ = fmt.Fprintf(w, "%4d  %v\n", i, x)
+	}
+}
+
+// Listing 返回指令清单
+func Listing(code []Instr) string {
+	var buf bytes.Buffer
+	Fprint(&buf, code)
+	return buf.String()
+}
@@ -0,0 +1,159 @@
+package pcode_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"pl0Compiler/parser"
+	"pl0Compiler/pcode"
+	"pl0Compiler/token"
+	"strings"
+	"testing"
+)
+
+// run 编译并在虚拟机上执行 src, 返回输出和 Run 的错误
+func run(t *testing.T, src, input string) (string, error) {
+	t.Helper()
+	program, err := parser.ParseFile(token.NewFileSet(), "test.pl", src)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	prog, err := pcode.NewCompiler().Compile(program)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	var out bytes.Buffer
+	err = pcode.NewVM(prog, strings.NewReader(input), &out).Run()
+	return out.String(), err
+}
+
+func TestEmptyStmt(t *testing.T) {
+	out, err := run(t, `
+var x;
+begin
+  x := 1;
+  if x > 0 then ;
+  while x < 3 do x := x + 1;
+  begin ; end;
+  read x;
+end.`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "3\n" {
+		t.Errorf("output = %q, want %q", out, "3\n")
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	out, err := run(t, `
+var x;
+begin
+  x := 6;
+  call println(x * 7);
+  call exit(3);
+  read x;
+end.`, "")
+	var exit *pcode.ExitError
+	if !errors.As(err, &exit) || exit.Code != 3 {
+		t.Fatalf("err = %v, want exit status 3", err)
+	}
+	if out != "42\n" {
+		t.Errorf("output = %q, want %q", out, "42\n")
+	}
+
+	out, err = run(t, "begin call println(1); call exit(0); call println(2); end.", "")
+	if err != nil {
+		t.Fatalf("exit(0): %v", err)
+	}
+	if out != "1\n" {
+		t.Errorf("output = %q, want %q", out, "1\n")
+	}
+}
+
+func TestProcedures(t *testing.T) {
+	out, err := run(t, `
+var x, r;
+procedure add(a, b);
+begin
+  r := a + b;
+end;
+begin
+  x := 5;
+  call add(x, 7);
+  read r;
+  write x;
+  read x;
+end.`, "9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "12\n9\n" {
+		t.Errorf("output = %q, want %q", out, "12\n9\n")
+	}
+}
+
+func TestDemo(t *testing.T) {
+	src, err := os.ReadFile("../demo/test2.pl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := run(t, string(src), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "11\n" {
+		t.Errorf("output = %q, want %q", out, "11\n")
+	}
+}
+
+func TestProcVars(t *testing.T) {
+	src, err := os.ReadFile("../demo/corpus/proc_vars.pl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := run(t, string(src), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "7\n595\n" {
+		t.Errorf("output = %q, want %q", out, "7\n595\n")
+	}
+}
+
+// TestOddNegative 检查 odd 对负数取最低位: odd(-3) 为真, odd(-4) 为假
+func TestOddNegative(t *testing.T) {
+	out, err := run(t, `
+var x;
+begin
+  x := -3;
+  if odd x then begin call println(1); end else begin call println(0); end;
+  x := -4;
+  if odd x then begin call println(1); end else begin call println(0); end;
+end.`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "1\n0\n" {
+		t.Errorf("output = %q, want %q", out, "1\n0\n")
+	}
+}
+
+// TestErrorPos 检查运行时错误报告出错的运算符或语句在源代码中的位置
+func TestErrorPos(t *testing.T) {
+	tests := []struct {
+		src, input, want string
+	}{
+		{"var x, y;\nbegin\n  write y;\n  x := 7 / y;\nend.", "0", "test.pl:4:10: division by zero"},
+		{"var x, y;\nbegin\n  write y;\n  x := 1 + 7 % y;\nend.", "0", "test.pl:4:14: division by zero"},
+		{"procedure p;\nbegin\n  call p;\nend;\nbegin call p; end.", "", "test.pl:3:3: stack overflow"},
+		{"var x;\nbegin\n  write x;\nend.", "abc", "test.pl:3:3: input: expected integer"},
+	}
+	for _, tt := range tests {
+		_, err := run(t, tt.src, tt.input)
+		var e *pcode.Error
+		if !errors.As(err, &e) || err.Error() != tt.want {
+			t.Errorf("%q: err = %v, want %s", tt.src, err, tt.want)
+		}
+	}
+}
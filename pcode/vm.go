@@ -0,0 +1,223 @@
+package pcode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"pl0Compiler/token"
+)
+
+// 栈的最大单元数
+const stackSize = 1 << 16
+
+// VM 执行 p-code 的栈式虚拟机.
+//
+// 每个活动记录的前三个单元依次为静态链(SL), 动态链(DL)和返回地址(RA),
+// 过程的实参由调用者在 CAL 之前压栈, 位于活动记录的负偏移处.
+type VM struct {
+	prog   *Program
+	code   []Instr
+	stack  []int64
+	stdin  *bufio.Reader
+	stdout io.Writer
+
+	p int // 指令指针
+	b int // 基址寄存器
+	t int // 栈顶寄存器
+}
+
+// Error 运行时错误, 有源代码位置时报告位置, 否则报告指令的下标
+type Error struct {
+	Pos token.Position
+	PC  int // 出错的指令的下标
+	Msg string
+}
+
+func (e *Error) Error() string {
+	if e.Pos.Filename != "" || e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Msg
+	}
+	return fmt.Sprintf("%d: %s", e.PC, e.Msg)
+}
+
+// ExitError 程序以非零的退出码调用了 exit
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string { return fmt.Sprintf("exit status %d", e.Code) }
+
+// ExitCode 返回退出码, 与 exec.ExitError 一致
+func (e *ExitError) ExitCode() int { return e.Code }
+
+func NewVM(prog *Program, stdin io.Reader, stdout io.Writer) *VM {
+	return &VM{
+		prog:   prog,
+		code:   prog.Code,
+		stack:  make([]int64, stackSize),
+		stdin:  bufio.NewReader(stdin),
+		stdout: stdout,
+	}
+}
+
+// base 沿静态链向外查找 l 层的活动记录基址
+func (vm *VM) base(l int) int {
+	b := vm.b
+	for ; l > 0; l-- {
+		b = int(vm.stack[b])
+	}
+	return b
+}
+
+// errorf 返回第 pc 条指令的运行时错误
+func (vm *VM) errorf(pc int, format string, args ...interface{}) error {
+	return &Error{Pos: vm.prog.Position(pc), PC: pc, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (vm *VM) push(x int64) error {
+	vm.t++
+	if vm.t >= len(vm.stack) {
+		return vm.errorf(vm.p-1, "stack overflow")
+	}
+	vm.stack[vm.t] = x
+	return nil
+}
+
+func (vm *VM) pop() int64 {
+	x := vm.stack[vm.t]
+	vm.t--
+	return x
+}
+
+func (vm *VM) Run() error {
+	vm.p, vm.b, vm.t = 0, 0, -1
+	vm.stack[0], vm.stack[1], vm.stack[2] = 0, 0, 0
+
+	for {
+		if vm.p < 0 || vm.p >= len(vm.code) {
+			return vm.errorf(vm.p, "pc %d out of range", vm.p)
+		}
+		pc := vm.p
+		i := vm.code[pc]
+		vm.p++
+
+		switch i.Op {
+		case LIT:
+			if err := vm.push(int64(int32(i.A))); err != nil {
+				return err
+			}
+		case OPR:
+			if i.A == OprExit {
+				if code := vm.pop(); code != 0 {
+					return &ExitError{Code: int(code)}
+				}
+				return nil
+			}
+			if i.A == OprRet {
+				vm.t = vm.b - 1
+				vm.p = int(vm.stack[vm.t+3])
+				vm.b = int(vm.stack[vm.t+2])
+				if vm.p == 0 {
+					return nil
+				}
+				continue
+			}
+			if err := vm.opr(pc, i.A); err != nil {
+				return err
+			}
+		case LOD:
+			if err := vm.push(vm.stack[vm.base(i.L)+i.A]); err != nil {
+				return err
+			}
+		case STO:
+			vm.stack[vm.base(i.L)+i.A] = vm.pop()
+		case CAL:
+			if vm.t+3 >= len(vm.stack) {
+				return vm.errorf(pc, "stack overflow")
+			}
+			vm.stack[vm.t+1] = int64(vm.base(i.L))
+			vm.stack[vm.t+2] = int64(vm.b)
+			vm.stack[vm.t+3] = int64(vm.p)
+			vm.b = vm.t + 1
+			vm.p = i.A
+		case INT:
+			vm.t += i.A
+			if vm.t >= len(vm.stack) {
+				return vm.errorf(pc, "stack overflow")
+			}
+		case JMP:
+			vm.p = i.A
+		case JPC:
+			if vm.pop() == 0 {
+				vm.p = i.A
+			}
+		default:
+			return vm.errorf(pc, "invalid instruction %v", i)
+		}
+	}
+}
+
+func (vm *VM) opr(pc, a int) error {
+	switch a {
+	case OprNeg:
+		vm.stack[vm.t] = int64(int32(-vm.stack[vm.t]))
+		return nil
+	case OprOdd:
+		vm.stack[vm.t] = vm.stack[vm.t] & 1
+		return nil
+	case OprPrint:
+		_, err := fmt.Fprintf(vm.stdout, "%d\n", vm.pop())
+		return err
+	case OprInput:
+		var x int64
+		if _, err := fmt.Fscan(vm.stdin, &x); err != nil {
+			return vm.errorf(pc, "input: %v", err)
+		}
+		return vm.push(int64(int32(x)))
+	}
+
+	y := vm.pop()
+	x := vm.stack[vm.t]
+	var r int64
+	switch a {
+	case OprAdd:
+		r = x + y
+	case OprSub:
+		r = x - y
+	case OprMul:
+		r = x * y
+	case OprDiv:
+		if y == 0 {
+			return vm.errorf(pc, "division by zero")
+		}
+		r = x / y
+	case OprMod:
+		if y == 0 {
+			return vm.errorf(pc, "division by zero")
+		}
+		r = x % y
+	case OprEql:
+		r = bool2int(x == y)
+	case OprNeq:
+		r = bool2int(x != y)
+	case OprLss:
+		r = bool2int(x < y)
+	case OprGeq:
+		r = bool2int(x >= y)
+	case OprGtr:
+		r = bool2int(x > y)
+	case OprLeq:
+		r = bool2int(x <= y)
+	default:
+		return vm.errorf(pc, "invalid instruction %v", vm.code[pc])
+	}
+	vm.stack[vm.t] = int64(int32(r))
+	return nil
+}
+
+func bool2int(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
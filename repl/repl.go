@@ -0,0 +1,180 @@
+// Package repl 实现 pl/0 的交互式解释器.
+//
+// 每次输入一行或多行声明和语句, 声明加入全局环境, 语句立即由解释器执行.
+// 输入没有结束时 (例如 begin 还没有对应的 end) 继续读入下一行.
+// 以 ':' 开头的行是命令, 例如 :print x 显示变量的值.
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"pl0Compiler/ast"
+	"pl0Compiler/interp"
+	"pl0Compiler/parser"
+	"pl0Compiler/token"
+	"sort"
+	"strings"
+)
+
+const (
+	prompt     = "pl0> "
+	contPrompt = "...> "
+)
+
+// REPL 交互式解释器, 在多次输入之间保持全局环境
+type REPL struct {
+	in     *bufio.Reader
+	out    io.Writer
+	fset   *token.FileSet
+	interp *interp.Interp
+	quit   bool
+	exit   *interp.ExitError // 输入的语句调用了 exit
+}
+
+// New 创建交互式解释器. 程序中的 write 语句与输入的代码共用 in.
+func New(in io.Reader, out io.Writer) *REPL {
+	r := &REPL{
+		in:   bufio.NewReader(in),
+		out:  out,
+		fset: token.NewFileSet(),
+	}
+	// bufio.NewReader 对已经足够大的 *bufio.Reader 直接返回, 解释器读取的是同一个缓冲
+	r.interp = interp.NewInterp(&ast.Program{FileSet: r.fset}, r.in, out)
+	return r
+}
+
+// SetIntWidth 设置整数的位数, bits 为 32 或 64
+func (r *REPL) SetIntWidth(bits int) {
+	r.interp.SetIntWidth(bits)
+}
+
+// Run 读取并执行输入, 直到输入结束, :quit 或调用 exit.
+// 以非零的退出码调用 exit 时返回 *interp.ExitError.
+func (r *REPL) Run() error {
+	var src strings.Builder
+	for !r.quit {
+		if src.Len() == 0 {
+			fmt.Fprint(r.out, prompt)
+		} else {
+			fmt.Fprint(r.out, contPrompt)
+		}
+		line, err := r.in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if line == "" && err == io.EOF {
+			if src.Len() > 0 {
+				// 输入在语句的中间结束, 报告不完整的语句
+				r.eval(src.String(), true)
+			}
+			fmt.Fprintln(r.out)
+			return r.exitErr()
+		}
+
+		if src.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			r.command(strings.Fields(strings.TrimSpace(line)))
+			continue
+		}
+		src.WriteString(line)
+		if r.eval(src.String(), err == io.EOF) {
+			src.Reset()
+		}
+	}
+	return r.exitErr()
+}
+
+// exitErr 返回 Run 的结果, exit(0) 视为正常结束
+func (r *REPL) exitErr() error {
+	if r.exit != nil && r.exit.Code != 0 {
+		return r.exit
+	}
+	return nil
+}
+
+// eval 解析并执行 src, 输入不完整时返回 false 以继续读入. final 为 true 时不再等待.
+func (r *REPL) eval(src string, final bool) bool {
+	nodes, incomplete, err := parser.ParseInput(r.fset, "<stdin>", src)
+	if incomplete && !final {
+		return false
+	}
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return true
+	}
+
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case *ast.ConstDecl, *ast.VarDecl, *ast.ProcDecl:
+			err = r.interp.Declare(node)
+		case ast.Stmt:
+			err = r.interp.Exec(node)
+		}
+		if e, ok := err.(*interp.ExitError); ok {
+			r.exit, r.quit = e, true
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+			break
+		}
+	}
+	return true
+}
+
+// command 执行以 ':' 开头的命令
+func (r *REPL) command(args []string) {
+	switch args[0] {
+	case ":print", ":p":
+		if len(args) == 1 {
+			fmt.Fprintln(r.out, "usage: :print name...")
+		}
+		for _, name := range args[1:] {
+			r.print(name)
+		}
+	case ":vars":
+		if env := r.interp.Globals(); env != nil {
+			names := make([]string, 0, len(env.Objects))
+			for name := range env.Objects {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				r.print(name)
+			}
+		}
+	case ":help":
+		fmt.Fprint(r.out, help)
+	case ":quit", ":q":
+		r.quit = true
+	default:
+		fmt.Fprintf(r.out, "unknown command %s, type :help for help\n", args[0])
+	}
+}
+
+// print 显示全局对象的值
+func (r *REPL) print(name string) {
+	var obj *interp.Object
+	if env := r.interp.Globals(); env != nil {
+		_, obj = env.Lookup(name)
+	}
+	switch {
+	case obj == nil:
+		fmt.Fprintf(r.out, "%s is not declared\n", name)
+	case obj.Kind == interp.Var:
+		fmt.Fprintf(r.out, "var %s = %d\n", obj.Name, obj.Value)
+	case obj.Kind == interp.Const:
+		fmt.Fprintf(r.out, "const %s = %d\n", obj.Name, obj.Value)
+	default:
+		fmt.Fprintf(r.out, "procedure %s\n", obj.Name)
+	}
+}
+
+const help = `Enter declarations (var, const, procedure) and statements.
+Statements are executed immediately; begin ... end may span several lines.
+Commands:
+  :print name...  show the values of variables and constants
+  :vars           show all declared names
+  :help           show this help
+  :quit           exit
+`
@@ -0,0 +1,91 @@
+package repl_test
+
+import (
+	"bytes"
+	"errors"
+	"pl0Compiler/interp"
+	"pl0Compiler/repl"
+	"strings"
+	"testing"
+)
+
+func TestREPL(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			// 声明和变量的值在多次输入之间保持
+			"state",
+			"var x;\nx := 5;\nx := x + 1;\nread x;\n",
+			"pl0> pl0> pl0> pl0> 6\npl0> \n",
+		},
+		{
+			// begin 没有对应的 end 时以 ...> 继续读入
+			"continuation",
+			"var y;\nbegin\n  y := 2;\n  read y;\n  y := y * 3;\nend;\nread y;\n",
+			"pl0> pl0> ...> ...> ...> ...> 2\npl0> 6\npl0> \n",
+		},
+		{
+			"procedure",
+			"var n;\nprocedure inc;\nbegin\n  n := n + 1;\nend;\ncall inc;\ncall inc;\nread n;\n",
+			"pl0> pl0> ...> ...> ...> pl0> pl0> pl0> 2\npl0> \n",
+		},
+		{
+			"print",
+			"var x;\nconst c = 10;\nprocedure p;;\nx := c + 1;\n:print x c p z\n:p\n",
+			"pl0> pl0> pl0> pl0> pl0> var x = 11\nconst c = 10\nprocedure p\nz is not declared\npl0> usage: :print name...\npl0> \n",
+		},
+		{
+			"vars",
+			"var b, a;\nconst c = 1;\n:vars\n",
+			"pl0> pl0> pl0> var a = 0\nvar b = 0\nconst c = 1\npl0> \n",
+		},
+		{
+			// write 读取的整数与输入的代码共用输入
+			"write",
+			"var x;\nwrite x;\n42\nread x;\n",
+			"pl0> pl0> pl0> pl0> 42\npl0> \n",
+		},
+		{
+			"errors",
+			"z := 1;\n:bogus\nvar x;\nread x;\n",
+			"pl0> <stdin>:1:1: var z undefined\npl0> unknown command :bogus, type :help for help\npl0> pl0> 0\npl0> \n",
+		},
+		{
+			// :quit 之后的输入不再执行
+			"quit",
+			"var x;\n:quit\nread x;\n",
+			"pl0> pl0> ",
+		},
+		{
+			// 输入在 begin ... end 的中间结束
+			"incomplete",
+			"var x;\nbegin\n  read x;\n",
+			"pl0> pl0> ...> ...> <stdin>:3:1: expect [end], got \"\"\n\n",
+		},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if err := repl.New(strings.NewReader(tt.input), &out).Run(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := out.String(); got != tt.want {
+			t.Errorf("%s: output = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExit(t *testing.T) {
+	var out bytes.Buffer
+	err := repl.New(strings.NewReader("var x;\nx := 1;\nread x;\ncall exit(3);\nread x;\n"), &out).Run()
+	var exit *interp.ExitError
+	if !errors.As(err, &exit) || exit.Code != 3 {
+		t.Errorf("err = %v, want exit status 3", err)
+	}
+	if want := "pl0> pl0> pl0> 1\npl0> "; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
@@ -2,7 +2,7 @@ package token
 
 import "fmt"
 
-// Pos 类似一个指针, 表示文件中的位置.
+// Pos 类似一个指针, 表示 FileSet 中的位置, 由 FileSet.Position 转为行列号.
 type Pos int
 
 // NoPos 类似指针的 nil 值, 表示一个无效的位置.
@@ -19,31 +19,6 @@ type Position struct {
 	Column   int    // 列号, 从 1 开始
 }
 
-// Position 装行列号位置
-func (p Pos) Position(fileName, src string) Position {
-	if !p.IsValid() {
-		return Position{
-			Filename: fileName,
-		}
-	}
-
-	var pos = Position{
-		Filename: fileName,
-		Offset:   int(p) - 1,
-		Line:     1,
-		Column:   1,
-	}
-
-	for _, c := range []byte(src[:pos.Offset]) {
-		pos.Column++
-		if c == '\n' {
-			pos.Column = 1
-			pos.Line++
-		}
-	}
-	return pos
-}
-
 func (pos Position) IsValid() bool {
 	return pos.Line > 0
 }
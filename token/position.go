@@ -0,0 +1,125 @@
+package token
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// File 记录一个源文件在 FileSet 中的位置范围和行表.
+type File struct {
+	name  string
+	base  int    // 文件第一个字节对应的 Pos
+	src   string // 源代码, 用于按 rune 计算列号
+	lines []int  // 每行第一个字节的偏移, lines[0] == 0
+}
+
+// Name 返回文件名
+func (f *File) Name() string { return f.name }
+
+// Base 返回文件第一个字节对应的 Pos
+func (f *File) Base() int { return f.base }
+
+// Size 返回文件的字节数
+func (f *File) Size() int { return len(f.src) }
+
+// LineCount 返回文件的行数
+func (f *File) LineCount() int { return len(f.lines) }
+
+// Pos 返回偏移 offset 对应的 Pos, offset 可以等于文件大小(文件结束)
+func (f *File) Pos(offset int) Pos {
+	if offset < 0 || offset > len(f.src) {
+		panic("token: illegal file offset")
+	}
+	return Pos(f.base + offset)
+}
+
+// Offset 返回 p 在文件中的偏移
+func (f *File) Offset(p Pos) int {
+	if int(p) < f.base || int(p) > f.base+len(f.src) {
+		panic("token: illegal Pos value")
+	}
+	return int(p) - f.base
+}
+
+// Line 返回 p 所在的行号
+func (f *File) Line(p Pos) int {
+	return f.Position(p).Line
+}
+
+// LineStart 返回第 line 行开始处的 Pos
+func (f *File) LineStart(line int) Pos {
+	if line < 1 || line > len(f.lines) {
+		panic("token: illegal line number")
+	}
+	return Pos(f.base + f.lines[line-1])
+}
+
+// Position 返回 p 对应的行列号, 列号以 rune 计
+func (f *File) Position(p Pos) Position {
+	if !p.IsValid() {
+		return Position{Filename: f.name}
+	}
+	offset := f.Offset(p)
+	i := sort.Search(len(f.lines), func(i int) bool { return f.lines[i] > offset }) - 1
+	return Position{
+		Filename: f.name,
+		Offset:   offset,
+		Line:     i + 1,
+		Column:   utf8.RuneCountInString(f.src[f.lines[i]:offset]) + 1,
+	}
+}
+
+// FileSet 表示一组源文件, 每个文件占用一段不相交的 Pos 区间.
+type FileSet struct {
+	base  int
+	files []*File
+}
+
+// NewFileSet 创建一个空的 FileSet, 第一个文件的 base 为 1 (0 为 NoPos)
+func NewFileSet() *FileSet {
+	return &FileSet{base: 1}
+}
+
+// AddFile 添加源文件并建立行表
+func (s *FileSet) AddFile(filename, src string) *File {
+	f := &File{
+		name:  filename,
+		base:  s.base,
+		src:   src,
+		lines: []int{0},
+	}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			f.lines = append(f.lines, i+1)
+		}
+	}
+	// 文件结束位置也是合法的 Pos, 文件之间空出一个位置
+	s.base += len(src) + 1
+	s.files = append(s.files, f)
+	return f
+}
+
+// File 返回包含 p 的文件, 找不到时返回 nil
+func (s *FileSet) File(p Pos) *File {
+	if !p.IsValid() {
+		return nil
+	}
+	i := sort.Search(len(s.files), func(i int) bool { return s.files[i].base > int(p) }) - 1
+	if i < 0 || int(p) > s.files[i].base+len(s.files[i].src) {
+		return nil
+	}
+	return s.files[i]
+}
+
+// Files 返回按添加顺序排列的文件
+func (s *FileSet) Files() []*File {
+	return s.files
+}
+
+// Position 返回 p 对应的行列号
+func (s *FileSet) Position(p Pos) Position {
+	if f := s.File(p); f != nil {
+		return f.Position(p)
+	}
+	return Position{}
+}
@@ -0,0 +1,150 @@
+package token_test
+
+import (
+	"pl0Compiler/token"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestPosition 检查偏移到行列号的转换, 列号按 rune 计算
+func TestPosition(t *testing.T) {
+	const src = "var x;\n\nbegin\n  x := 1; // 注释\n  { 中文 } x := 2;\nend.\n"
+	fset := token.NewFileSet()
+	f := fset.AddFile("test.pl", src)
+	if f.LineCount() != 7 {
+		t.Errorf("LineCount = %d, want 7", f.LineCount())
+	}
+	tests := []struct {
+		offset    int
+		line, col int
+		desc      string
+	}{
+		{0, 1, 1, "文件开头"},
+		{4, 1, 5, "x"},
+		{6, 1, 7, "第一行的换行符"},
+		{7, 2, 1, "空行"},
+		{8, 3, 1, "begin"},
+		{16, 4, 3, "第四行的 x"},
+		{24, 4, 11, "//"},
+		{27, 4, 14, "注"},
+		{30, 4, 15, "释"},
+		{33, 4, 16, "第四行的换行符"},
+		{34, 5, 1, "第五行开头"},
+		{38, 5, 5, "中"},
+		{47, 5, 10, "中文之后的 x"},
+		{55, 6, 1, "end"},
+		{len(src), 7, 1, "文件结束"},
+	}
+	for _, tt := range tests {
+		pos := f.Pos(tt.offset)
+		got := fset.Position(pos)
+		want := token.Position{Filename: "test.pl", Offset: tt.offset, Line: tt.line, Column: tt.col}
+		if got != want {
+			t.Errorf("%s: Position(%d) = %+v, want %+v", tt.desc, tt.offset, got, want)
+		}
+		if f.Offset(pos) != tt.offset {
+			t.Errorf("%s: Offset(Pos(%d)) = %d", tt.desc, tt.offset, f.Offset(pos))
+		}
+		if f.Line(pos) != tt.line {
+			t.Errorf("%s: Line = %d, want %d", tt.desc, f.Line(pos), tt.line)
+		}
+	}
+
+	for line, offset := range []int{0, 7, 8, 14, 34, 55, 60} {
+		if got := f.LineStart(line + 1); got != f.Pos(offset) {
+			t.Errorf("LineStart(%d) = %d, want %d", line+1, got, f.Pos(offset))
+		}
+	}
+}
+
+// TestPositionLines 在行数较多的文件中把二分查找的结果与逐字节扫描的结果比较
+func TestPositionLines(t *testing.T) {
+	var src []byte
+	for i := 0; i < 500; i++ {
+		src = append(src, strings.Repeat("x", i%7)...)
+		if i%3 == 0 {
+			src = append(src, "中"...)
+		}
+		src = append(src, '\n')
+	}
+	f := token.NewFileSet().AddFile("lines.pl", string(src))
+	line, col := 1, 1
+	for offset := 0; offset <= len(src); {
+		pos := f.Position(f.Pos(offset))
+		if pos.Line != line || pos.Column != col {
+			t.Fatalf("Position(%d) = %d:%d, want %d:%d", offset, pos.Line, pos.Column, line, col)
+		}
+		if offset == len(src) {
+			break
+		}
+		_, size := utf8.DecodeRune(src[offset:])
+		if src[offset] == '\n' {
+			line, col = line+1, 1
+		} else {
+			col++
+		}
+		offset += size
+	}
+	if line != 501 || f.LineCount() != 501 {
+		t.Errorf("lines = %d, LineCount = %d, want 501", line, f.LineCount())
+	}
+}
+
+// TestFileSet 检查多个文件的 Pos 互不重叠, 并能找回所在的文件
+func TestFileSet(t *testing.T) {
+	fset := token.NewFileSet()
+	a := fset.AddFile("a.pl", "var a;\n")
+	b := fset.AddFile("b.pl", "")
+	c := fset.AddFile("c.pl", "const c = 1;\nbegin end.")
+
+	if a.Base() != 1 || b.Base() != a.Base()+a.Size()+1 || c.Base() != b.Base()+b.Size()+1 {
+		t.Errorf("bases = %d, %d, %d", a.Base(), b.Base(), c.Base())
+	}
+	if files := fset.Files(); len(files) != 3 || files[0] != a || files[1] != b || files[2] != c {
+		t.Errorf("Files = %v, want [a b c]", files)
+	}
+
+	tests := []struct {
+		pos  token.Pos
+		file *token.File
+		want string
+	}{
+		{token.NoPos, nil, "-"},
+		{a.Pos(0), a, "a.pl:1:1"},
+		{a.Pos(4), a, "a.pl:1:5"},
+		{a.Pos(a.Size()), a, "a.pl:2:1"}, // a 的结束位置
+		{b.Pos(0), b, "b.pl:1:1"},        // 空文件的开头也是结束位置
+		{c.Pos(0), c, "c.pl:1:1"},
+		{c.Pos(13), c, "c.pl:2:1"},
+		{c.Pos(c.Size()), c, "c.pl:2:11"},
+		{c.Pos(c.Size()) + 1, nil, "-"}, // 最后一个文件之后
+	}
+	for _, tt := range tests {
+		if f := fset.File(tt.pos); f != tt.file {
+			t.Errorf("File(%d) = %v, want %v", tt.pos, f, tt.file)
+		}
+		if got := fset.Position(tt.pos).String(); got != tt.want {
+			t.Errorf("Position(%d) = %s, want %s", tt.pos, got, tt.want)
+		}
+	}
+}
+
+func TestPositionString(t *testing.T) {
+	tests := []struct {
+		pos  token.Position
+		want string
+	}{
+		{token.Position{Filename: "a.pl", Line: 3, Column: 7}, "a.pl:3:7"},
+		{token.Position{Filename: "a.pl", Line: 3}, "a.pl:3"},
+		{token.Position{Line: 3, Column: 7}, "3:7"},
+		{token.Position{Line: 3}, "3"},
+		{token.Position{Filename: "a.pl"}, "a.pl"},
+		{token.Position{}, "-"},
+	}
+	for _, tt := range tests {
+		if got := tt.pos.String(); got != tt.want {
+			t.Errorf("%+v: String() = %q, want %q", tt.pos, got, tt.want)
+		}
+	}
+}
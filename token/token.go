@@ -23,6 +23,7 @@ const (
 
 	IDENT
 	NUMBER
+	STRING // "lib/math.pl"
 
 	BEGIN
 	CALL
@@ -40,11 +41,13 @@ const (
 	UNTIL
 	READ
 	WRITE
+	IMPORT
 
 	ADD // +
 	SUB // -
 	MUL // *
 	DIV // /
+	MOD // %
 
 	EQL // =
 	NEQ // <>
@@ -69,7 +72,7 @@ func (op TokenType) Precedence() int {
 		return 1
 	case ADD, SUB:
 		return 2
-	case MUL, DIV:
+	case MUL, DIV, MOD:
 		return 3
 	}
 	return 0
@@ -82,6 +85,7 @@ var tokens = [...]string{
 
 	IDENT:  "IDENT",
 	NUMBER: "NUMBER",
+	STRING: "STRING",
 
 	BEGIN:     "begin",
 	CALL:      "call",
@@ -99,11 +103,13 @@ var tokens = [...]string{
 	UNTIL:     "until",
 	READ:      "read",
 	WRITE:     "write",
+	IMPORT:    "import",
 
 	ADD: "+",
 	SUB: "-",
 	MUL: "*",
 	DIV: "/",
+	MOD: "%",
 
 	EQL: "=",
 	NEQ: "<>",
@@ -162,6 +168,7 @@ var keywords = map[string]TokenType{
 	"until":     UNTIL,
 	"read":      READ,
 	"write":     WRITE,
+	"import":    IMPORT,
 }
 
 func LoopUp(ident string) TokenType {
@@ -0,0 +1,159 @@
+package vet
+
+import (
+	"pl0Compiler/ast"
+	"pl0Compiler/compiler"
+	"pl0Compiler/constant"
+	"pl0Compiler/token"
+)
+
+// Unreachable 报告死循环之后的语句. pl/0 没有跳出循环的语句,
+// 条件恒为真的 while 和条件恒为假的 repeat 之后的语句都不会执行.
+var Unreachable = &Analyzer{
+	Name: "unreachable",
+	Doc:  "report statements after a loop that never ends",
+	Run:  runUnreachable,
+}
+
+// RepeatCond 报告循环体中不会改变的 repeat 条件.
+// 循环体中有过程调用时, 被调用的过程可能修改条件中的变量, 不报告.
+var RepeatCond = &Analyzer{
+	Name: "repeatcond",
+	Doc:  "report repeat loops whose condition is not changed by the body",
+	Run:  runRepeatCond,
+}
+
+func runUnreachable(pass *Pass) {
+	ast.Inspect(pass.Program, func(n ast.Node) bool {
+		block, ok := n.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		for i, s := range block.List {
+			if s == nil || pass.terminates(s) {
+				continue
+			}
+			for _, next := range block.List[i+1:] {
+				if next != nil {
+					pass.Reportf(next.Pos(), "unreachable code")
+					return true
+				}
+			}
+		}
+		return true
+	})
+}
+
+// terminates 语句可能执行结束时返回 true
+func (p *Pass) terminates(s ast.Stmt) bool {
+	switch s := s.(type) {
+	case *ast.WhileStmt:
+		if v, ok := p.constCond(s.Cond); ok && v {
+			return false
+		}
+	case *ast.RepeatStmt:
+		if v, ok := p.constCond(s.Cond); ok && !v {
+			return false
+		}
+		return p.terminates(s.Body)
+	case *ast.BlockStmt:
+		for _, x := range s.List {
+			if x != nil && !p.terminates(x) {
+				return false
+			}
+		}
+	case *ast.IfStmt:
+		if s.Else != nil && !p.terminates(s.Body) && !p.terminates(s.Else) {
+			return false
+		}
+	}
+	return true
+}
+
+func runRepeatCond(pass *Pass) {
+	ast.Inspect(pass.Program, func(n ast.Node) bool {
+		loop, ok := n.(*ast.RepeatStmt)
+		if !ok {
+			return true
+		}
+
+		written := make(map[*compiler.Object]bool)
+		calls := false
+		ast.Inspect(loop.Body, func(n ast.Node) bool {
+			if _, ok := n.(*ast.CallStmt); ok {
+				calls = true
+			}
+			return true
+		})
+		refs(loop.Body, func(ident *ast.Ident, write bool) {
+			if write {
+				written[pass.object(ident)] = true
+			}
+		})
+		if calls {
+			return true
+		}
+
+		changed := false
+		refs(loop.Cond, func(ident *ast.Ident, write bool) {
+			if written[pass.object(ident)] {
+				changed = true
+			}
+		})
+		if !changed {
+			pass.Reportf(loop.Cond.Pos(), "repeat condition is never changed by the loop body")
+		}
+		return true
+	})
+}
+
+// constCond 对常量条件求值, 条件不是常量时 ok 为 false
+func (p *Pass) constCond(expr ast.Expr) (value, ok bool) {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return p.constCond(expr.X)
+	case *ast.UnaryExpr:
+		if expr.Op == token.ODD {
+			x, ok := p.constValue(expr.X)
+			return x%2 != 0, ok
+		}
+	case *ast.BinaryExpr:
+		x, okx := p.constValue(expr.X)
+		y, oky := p.constValue(expr.Y)
+		if !okx || !oky {
+			return false, false
+		}
+		switch expr.Op {
+		case token.EQL:
+			return x == y, true
+		case token.NEQ:
+			return x != y, true
+		case token.LSS:
+			return x < y, true
+		case token.LEQ:
+			return x <= y, true
+		case token.GTR:
+			return x > y, true
+		case token.GEQ:
+			return x >= y, true
+		}
+	}
+	return false, false
+}
+
+// constValue 对常量表达式求值, 名字按其引用的对象取值
+func (p *Pass) constValue(expr ast.Expr) (int64, bool) {
+	consts := make(map[string]*compiler.Object)
+	refs(expr, func(ident *ast.Ident, write bool) {
+		if obj := p.object(ident); obj != nil && obj.Kind == compiler.Con {
+			consts[ident.Name] = obj
+		}
+	})
+	value, err := constant.Eval(expr, func(name string) (int64, bool) {
+		if obj := consts[name]; obj != nil {
+			return obj.Value, true
+		}
+		return 0, false
+	})
+	return value, err == nil
+}
@@ -0,0 +1,23 @@
+package vet
+
+// Shadow 报告遮蔽全局声明的局部声明
+var Shadow = &Analyzer{
+	Name: "shadow",
+	Doc:  "report local declarations that shadow a global",
+	Run:  runShadow,
+}
+
+func runShadow(pass *Pass) {
+	top := pass.Info.Scopes[pass.Program]
+	for _, s := range pass.scopes() {
+		if s == top {
+			continue
+		}
+		for _, obj := range sortedObjects(s) {
+			if scope, global := s.Outer.Lookup(obj.Name); scope == top {
+				pass.Reportf(declPos(obj), "%s %s shadows global %s declared at %s",
+					obj.Kind, obj.Name, global.Kind, pass.Program.FileSet.Position(declPos(global)))
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package vet
+
+import (
+	"pl0Compiler/ast"
+	"pl0Compiler/compiler"
+)
+
+// Uninit 报告在任何赋值之前读取的局部变量.
+// 按源代码的顺序判断, 只要之前有赋值就不报告, 不考虑分支和循环.
+var Uninit = &Analyzer{
+	Name: "uninit",
+	Doc:  "report local variables read before any assignment",
+	Run:  runUninit,
+}
+
+func runUninit(pass *Pass) {
+	pass.bodies(func(proc *ast.ProcDecl, body *ast.BlockStmt) {
+		locals := make(map[*compiler.Object]bool)
+		declare := func(decl *ast.VarDecl) {
+			for _, name := range decl.Names {
+				if obj := pass.Info.Defs[name]; obj != nil {
+					locals[obj] = true
+				}
+			}
+		}
+		if proc != nil && proc.VarDecl != nil {
+			declare(proc.VarDecl)
+		}
+		ast.Inspect(body, func(n ast.Node) bool {
+			if decl, ok := n.(*ast.VarDecl); ok {
+				declare(decl)
+			}
+			return true
+		})
+
+		// 嵌套的过程可以给局部变量赋值, 调用之后无法确定是否已赋值
+		if proc != nil {
+			for _, sub := range proc.Funcs {
+				refs(sub, func(ident *ast.Ident, write bool) {
+					if write {
+						delete(locals, pass.object(ident))
+					}
+				})
+			}
+		}
+
+		assigned := make(map[*compiler.Object]bool)
+		refs(body, func(ident *ast.Ident, write bool) {
+			obj := pass.object(ident)
+			if !locals[obj] || assigned[obj] {
+				return
+			}
+			if !write {
+				pass.Reportf(ident.NamePos, "variable %s is read before it is assigned", ident.Name)
+			}
+			// 每个变量只报告一次
+			assigned[obj] = true
+		})
+	})
+}
@@ -0,0 +1,90 @@
+package vet
+
+import (
+	"pl0Compiler/ast"
+	"pl0Compiler/compiler"
+)
+
+// Unused 报告从未读取的变量和常量
+var Unused = &Analyzer{
+	Name: "unused",
+	Doc:  "report variables and constants that are never read",
+	Run:  runUnused,
+}
+
+// Uncalled 报告从未被调用的过程, 过程对自己的递归调用不算
+var Uncalled = &Analyzer{
+	Name: "uncalled",
+	Doc:  "report procedures that are never called",
+	Run:  runUncalled,
+}
+
+// UnusedParam 报告从未读取的过程参数
+var UnusedParam = &Analyzer{
+	Name: "unusedparam",
+	Doc:  "report procedure parameters that are never read",
+	Run:  runUnusedParam,
+}
+
+func runUnused(pass *Pass) {
+	read := pass.reads()
+	top := pass.Info.Scopes[pass.Program]
+	for _, s := range pass.scopes() {
+		if s == top && pass.library() {
+			continue
+		}
+		for _, obj := range sortedObjects(s) {
+			if read[obj] {
+				continue
+			}
+			switch obj.Kind {
+			case compiler.Var:
+				pass.Reportf(declPos(obj), "variable %s is never read", obj.Name)
+			case compiler.Con:
+				pass.Reportf(declPos(obj), "constant %s is never used", obj.Name)
+			}
+		}
+	}
+}
+
+func runUncalled(pass *Pass) {
+	called := make(map[ast.Node]bool)
+	pass.bodies(func(proc *ast.ProcDecl, body *ast.BlockStmt) {
+		refs(body, func(ident *ast.Ident, write bool) {
+			obj := pass.object(ident)
+			if obj == nil || obj.Kind != compiler.Proc {
+				return
+			}
+			if proc == nil || obj.Node != ast.Node(proc) {
+				called[obj.Node] = true
+			}
+		})
+	})
+
+	var visit func(funcs []*ast.ProcDecl, exported bool)
+	visit = func(funcs []*ast.ProcDecl, exported bool) {
+		for _, fn := range funcs {
+			if !exported && !called[fn] {
+				pass.Reportf(fn.NamePos, "procedure %s is never called", fn.Name)
+			}
+			visit(fn.Funcs, false)
+		}
+	}
+	visit(pass.Program.Funcs, pass.library())
+}
+
+func runUnusedParam(pass *Pass) {
+	read := pass.reads()
+	ast.Inspect(pass.Program, func(n ast.Node) bool {
+		fn, ok := n.(*ast.ProcDecl)
+		if !ok || fn.Params == nil {
+			return true
+		}
+		for _, field := range fn.Params.List {
+			if obj := pass.Info.Defs[field.Name]; obj != nil && !read[obj] {
+				pass.Reportf(field.Name.NamePos, "parameter %s of %s is never read", field.Name.Name, fn.Name)
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,244 @@
+// Package vet 在语义检查通过的程序上做静态分析, 报告可疑但合法的代码.
+//
+// 每项检查是一个有名字的 Analyzer, 可以按名字启用或禁用.
+package vet
+
+import (
+	"fmt"
+	"pl0Compiler/ast"
+	"pl0Compiler/check"
+	"pl0Compiler/compiler"
+	"pl0Compiler/token"
+	"sort"
+)
+
+// Analyzer 一项检查
+type Analyzer struct {
+	Name string // 检查的名字, 用于启用和禁用
+	Doc  string // 一行说明
+	Run  func(pass *Pass)
+}
+
+// Analyzers 全部检查, 默认都启用
+var Analyzers = []*Analyzer{
+	Unused,
+	Uncalled,
+	UnusedParam,
+	Uninit,
+	Shadow,
+	Unreachable,
+	RepeatCond,
+}
+
+// Pass 一项检查运行时可用的信息
+type Pass struct {
+	Analyzer *Analyzer
+	Program  *ast.Program // 被检查的程序, 不包括导入的模块
+	Info     *check.Info  // 语义检查的结果
+
+	diagnostics *[]Diagnostic
+}
+
+// Reportf 报告 pos 处的问题
+func (p *Pass) Reportf(pos token.Pos, format string, args ...interface{}) {
+	*p.diagnostics = append(*p.diagnostics, Diagnostic{
+		Pos:   p.Program.FileSet.Position(pos),
+		Check: p.Analyzer.Name,
+		Msg:   fmt.Sprintf(format, args...),
+	})
+}
+
+// Diagnostic 一条检查结果
+type Diagnostic struct {
+	Pos   token.Position
+	Check string // 报告问题的检查
+	Msg   string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s (%s)", d.Pos, d.Msg, d.Check)
+}
+
+// Option 选择运行的检查. Enable 不为空时只运行其中的检查, 之后去掉 Disable 中的检查.
+type Option struct {
+	Enable  []string
+	Disable []string
+}
+
+// Lookup 按名字查找检查, 不存在时返回 nil
+func Lookup(name string) *Analyzer {
+	for _, a := range Analyzers {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Select 返回 opt 选择的检查, 名字不存在时返回错误
+func Select(opt *Option) ([]*Analyzer, error) {
+	if opt == nil {
+		return Analyzers, nil
+	}
+	enabled := make(map[*Analyzer]bool)
+	for _, name := range opt.Enable {
+		a := Lookup(name)
+		if a == nil {
+			return nil, fmt.Errorf("unknown check %q", name)
+		}
+		enabled[a] = true
+	}
+	if len(opt.Enable) == 0 {
+		for _, a := range Analyzers {
+			enabled[a] = true
+		}
+	}
+	for _, name := range opt.Disable {
+		a := Lookup(name)
+		if a == nil {
+			return nil, fmt.Errorf("unknown check %q", name)
+		}
+		delete(enabled, a)
+	}
+
+	var list []*Analyzer
+	for _, a := range Analyzers {
+		if enabled[a] {
+			list = append(list, a)
+		}
+	}
+	return list, nil
+}
+
+// Vet 对已经通过 check.Check 的程序运行 opt 选择的检查, 结果按位置排序.
+// opt 为 nil 时运行全部检查.
+func Vet(program *ast.Program, info *check.Info, opt *Option) ([]Diagnostic, error) {
+	analyzers, err := Select(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	var diagnostics []Diagnostic
+	for _, a := range analyzers {
+		a.Run(&Pass{
+			Analyzer:    a,
+			Program:     program,
+			Info:        info,
+			diagnostics: &diagnostics,
+		})
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		x, y := diagnostics[i].Pos, diagnostics[j].Pos
+		if x.Filename != y.Filename {
+			return x.Filename < y.Filename
+		}
+		if x.Line != y.Line {
+			return x.Line < y.Line
+		}
+		return x.Column < y.Column
+	})
+	return diagnostics, nil
+}
+
+// refs 按源代码的顺序遍历 node 中引用的标识符, 不包括声明处的标识符.
+// write 表示标识符被赋值: 赋值语句的目标和 write 语句读入的变量.
+func refs(node ast.Node, fn func(ident *ast.Ident, write bool)) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			refs(n.Value, fn)
+			fn(n.Target, true)
+			return false
+		case *ast.IOStmt:
+			for _, param := range n.Params.List {
+				fn(param.Name, n.Type == token.WRITE)
+			}
+			return false
+		case *ast.DefineStmt:
+			refs(n.Value, fn)
+			return false
+		case *ast.VarDecl, *ast.FieldList:
+			return false
+		case *ast.Ident:
+			fn(n, false)
+		}
+		return true
+	})
+}
+
+// object 返回标识符引用的对象
+func (p *Pass) object(ident *ast.Ident) *compiler.Object {
+	return p.Info.Uses[ident]
+}
+
+// library 程序没有入口时为模块, 顶层声明供其它程序导入, 不报告未使用
+func (p *Pass) library() bool {
+	return p.Program.Stmt == nil
+}
+
+// declPos 返回对象声明处的位置
+func declPos(obj *compiler.Object) token.Pos {
+	switch node := obj.Node.(type) {
+	case *ast.Ident:
+		return node.NamePos
+	case *ast.DefineStmt:
+		return node.Target.NamePos
+	case *ast.ProcDecl:
+		return node.NamePos
+	}
+	return token.NoPos
+}
+
+// scopes 返回程序中的全部作用域, 不包括导入的模块
+func (p *Pass) scopes() []*compiler.Scope {
+	var list []*compiler.Scope
+	ast.Inspect(p.Program, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if s := p.Info.Scopes[n]; s != nil {
+			list = append(list, s)
+		}
+		return true
+	})
+	return list
+}
+
+// sortedObjects 按名字排序返回作用域中的对象
+func sortedObjects(s *compiler.Scope) []*compiler.Object {
+	names := make([]string, 0, len(s.Objects))
+	for name := range s.Objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]*compiler.Object, len(names))
+	for i, name := range names {
+		list[i] = s.Objects[name]
+	}
+	return list
+}
+
+// reads 返回程序中被读取过的对象
+func (p *Pass) reads() map[*compiler.Object]bool {
+	read := make(map[*compiler.Object]bool)
+	refs(p.Program, func(ident *ast.Ident, write bool) {
+		if obj := p.object(ident); obj != nil && !write {
+			read[obj] = true
+		}
+	})
+	return read
+}
+
+// bodies 对程序入口和每个过程体调用 fn, 程序入口的 proc 为 nil
+func (p *Pass) bodies(fn func(proc *ast.ProcDecl, body *ast.BlockStmt)) {
+	if p.Program.Stmt != nil {
+		fn(nil, p.Program.Stmt)
+	}
+	ast.Inspect(p.Program, func(n ast.Node) bool {
+		if proc, ok := n.(*ast.ProcDecl); ok && proc.Body != nil {
+			fn(proc, proc.Body)
+		}
+		return true
+	})
+}
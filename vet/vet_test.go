@@ -0,0 +1,112 @@
+package vet_test
+
+import (
+	"fmt"
+	"pl0Compiler/build"
+	"pl0Compiler/vet"
+	"reflect"
+	"testing"
+)
+
+// diagnostics 检查 src 并运行 opt 选择的检查, 结果格式为 "line:col: msg (check)"
+func diagnostics(t *testing.T, src string, opt *vet.Option) []string {
+	t.Helper()
+	list, err := build.NewContext(nil).Vet([]string{"test.pl"}, []interface{}{src}, opt)
+	if err != nil {
+		t.Fatalf("%q: %v", src, err)
+	}
+	var got []string
+	for _, d := range list {
+		got = append(got, fmt.Sprintf("%d:%d: %s (%s)", d.Pos.Line, d.Pos.Column, d.Msg, d.Check))
+	}
+	return got
+}
+
+func TestChecks(t *testing.T) {
+	tests := []struct {
+		check string
+		src   string
+		want  []string
+	}{
+		{"unused", "var x, y;\nbegin x := 1; y := 2; read y; end.", []string{
+			"1:5: variable x is never read (unused)",
+		}},
+		{"unused", "const c = 1;\nvar x;\nbegin x := 2; read x; end.", []string{
+			"1:7: constant c is never used (unused)",
+		}},
+		{"unused", "const c = 1;\nvar x;\nbegin x := c; read x; end.", nil},
+
+		{"uncalled", "procedure p;;\nbegin end.", []string{
+			"1:11: procedure p is never called (uncalled)",
+		}},
+		{"uncalled", "procedure p;;\nbegin call p; end.", nil},
+
+		{"unusedparam", "procedure p(a, b);\nbegin read b; end;\nbegin call p(1, 2); end.", []string{
+			"1:13: parameter a of p is never read (unusedparam)",
+		}},
+		{"unusedparam", "procedure p(a);\nbegin read a; end;\nbegin call p(1); end.", nil},
+
+		{"uninit", "procedure p;\nvar y;\nbegin read y; y := 1; end;\nbegin call p; end.", []string{
+			"3:12: variable y is read before it is assigned (uninit)",
+		}},
+		{"uninit", "procedure p;\nvar y;\nbegin y := 1; read y; end;\nbegin call p; end.", nil},
+
+		{"shadow", "var x;\nprocedure p;\nvar x;\nbegin x := 1; read x; end;\nbegin x := 2; call p; end.", []string{
+			"3:5: var x shadows global var declared at test.pl:1:5 (shadow)",
+		}},
+		{"shadow", "var x;\nprocedure p;\nvar y;\nbegin y := x; read y; end;\nbegin x := 2; call p; end.", nil},
+
+		{"unreachable", "var x;\nbegin\n  while 1 = 1 do x := x + 1;\n  read x;\nend.", []string{
+			"4:3: unreachable code (unreachable)",
+		}},
+		{"unreachable", "var x;\nbegin\n  while x < 3 do x := x + 1;\n  read x;\nend.", nil},
+
+		{"repeatcond", "var x, y;\nbegin\n  x := 0;\n  repeat y := y + 1; until x > 3;\n  read y;\nend.", []string{
+			"4:28: repeat condition is never changed by the loop body (repeatcond)",
+		}},
+		{"repeatcond", "var x;\nbegin\n  x := 0;\n  repeat x := x + 1; until x > 3;\n  read x;\nend.", nil},
+	}
+	for _, tt := range tests {
+		got := diagnostics(t, tt.src, &vet.Option{Enable: []string{tt.check}})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %q\ngot  %q\nwant %q", tt.check, tt.src, got, tt.want)
+		}
+	}
+}
+
+// TestSelect 检查 Enable 和 Disable 选择运行的检查
+func TestSelect(t *testing.T) {
+	// x 从未读取, p 从未调用
+	const src = "var x;\nprocedure p;;\nbegin x := 1; end."
+	tests := []struct {
+		opt  *vet.Option
+		want []string
+	}{
+		{nil, []string{
+			"1:5: variable x is never read (unused)",
+			"2:11: procedure p is never called (uncalled)",
+		}},
+		{&vet.Option{Disable: []string{"unused"}}, []string{
+			"2:11: procedure p is never called (uncalled)",
+		}},
+		{&vet.Option{Enable: []string{"unused"}}, []string{
+			"1:5: variable x is never read (unused)",
+		}},
+		{&vet.Option{Enable: []string{"unused", "uncalled"}, Disable: []string{"uncalled"}}, []string{
+			"1:5: variable x is never read (unused)",
+		}},
+		{&vet.Option{Disable: []string{"unused", "uncalled"}}, nil},
+	}
+	for _, tt := range tests {
+		got := diagnostics(t, src, tt.opt)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v:\ngot  %q\nwant %q", tt.opt, got, tt.want)
+		}
+	}
+
+	for _, opt := range []*vet.Option{{Enable: []string{"nope"}}, {Disable: []string{"nope"}}} {
+		if _, err := vet.Select(opt); err == nil || err.Error() != `unknown check "nope"` {
+			t.Errorf("Select(%+v) error = %v, want unknown check", opt, err)
+		}
+	}
+}